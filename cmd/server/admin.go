@@ -0,0 +1,57 @@
+package server
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/ports"
+	"e-commerce/internal/util"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bootstrapAdmin gives the account ADMIN_EMAIL admin rights, creating it with
+// ADMIN_PASSWORD if it does not exist yet. Admins cannot sign up, so this is how the
+// platform gets one. An existing account is only promoted if ADMIN_PASSWORD is its
+// password, so whoever registered the address first cannot become admin by accident.
+func bootstrapAdmin(repository ports.Repository) {
+	email := strings.TrimSpace(os.Getenv("ADMIN_EMAIL"))
+	password := os.Getenv("ADMIN_PASSWORD")
+	if email == "" || password == "" {
+		return
+	}
+
+	if _, err := repository.FindAdminByEmail(email); err == nil {
+		return
+	}
+
+	admin := &models.Admin{}
+	if identity, err := repository.FindAccountByEmail(email); err == nil {
+		if bcrypt.CompareHashAndPassword([]byte(identity.Password), []byte(password)) != nil {
+			log.Printf("bootstrap admin: account %s exists with a different password, not promoting it\n", email)
+			return
+		}
+		admin.AccountID, admin.Account = identity.ID, identity
+	} else {
+		hashedPassword, err := util.HashPassword(password)
+		if err != nil {
+			log.Printf("bootstrap admin errors: %v\n", err)
+			return
+		}
+		now := time.Now()
+		admin.Account = &models.Account{
+			FirstName:       "Admin",
+			Email:           email,
+			Password:        hashedPassword,
+			EmailVerifiedAt: &now,
+		}
+	}
+
+	if err := repository.CreateAdmin(admin); err != nil {
+		log.Printf("bootstrap admin errors: %v\n", err)
+		return
+	}
+	log.Printf("created admin %s\n", email)
+}
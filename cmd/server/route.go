@@ -3,16 +3,23 @@ package server
 import (
 	"e-commerce/internal/api"
 	"e-commerce/internal/middleware"
+	"e-commerce/internal/models"
 	"e-commerce/internal/ports"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 // SetupRouter is where router endpoints are called
 func SetupRouter(handler *api.HTTPHandler, repository ports.Repository) *gin.Engine {
+	// Request bodies bind into dedicated request types; a field they do not declare is
+	// a client error rather than something to ignore
+	binding.EnableDecoderDisallowUnknownFields = true
+
 	router := gin.Default()
+	verification := middleware.LoadVerificationPolicy()
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"POST", "GET", "PUT", "PATCH", "DELETE"},
@@ -25,26 +32,67 @@ func SetupRouter(handler *api.HTTPHandler, repository ports.Repository) *gin.Eng
 	r := router.Group("/")
 	{
 		r.GET("/", handler.Readiness)
+		r.GET("/.well-known/jwks.json", handler.JWKS)
+		r.GET("/wishlist/shared/:token", handler.ViewSharedWishlist)
+		r.GET("/products/search", handler.SearchProducts)
+		r.GET("/categories", handler.ListCategories)
+		r.GET("/categories/:id/products", handler.ListCategoryProducts)
 	}
 
 	user := r.Group("/user")
 	{
 		user.POST("/create", handler.CreateUser)
 		user.POST("/login", handler.LoginUser)
+		user.POST("/login/2fa", handler.LoginUserTwoFactor)
+		user.POST("/token/refresh", handler.RefreshUserToken)
+		user.POST("/password/forgot", handler.ForgotUserPassword)
+		user.POST("/password/reset", handler.ResetUserPassword)
+		user.POST("/email/verify", handler.VerifyUserEmail)
+		user.POST("/email/resend", handler.ResendUserVerification)
 	}
 
 	// AuthorizeUser authorizes all the authorized users haldlers
-	user.Use(middleware.AuthorizeUser(repository.FindUserByEmail, repository.TokenInBlacklist))
+	user.Use(middleware.AuthorizeUser(handler.Keys, repository.GetUserByAccountID, repository.TokenInBlacklist, repository.TouchSession))
 	{
 		user.GET("/product/all", handler.GetAllProducts)
 		user.GET("/product/:id", handler.GetProductByID)
+		user.GET("/product/:id/reviews", handler.ListProductReviews)
+		user.POST("/product/:id/reviews", handler.CreateReview)
 		user.POST("/logout", handler.Logout)
-		user.POST("/cart/add", handler.AddProductToCart)
-		user.PUT("/cart/edit", handler.EditCart)
+		user.POST("/2fa/enroll", handler.EnrollUserTwoFactor)
+		user.POST("/2fa/confirm", handler.ConfirmUserTwoFactor)
+		user.POST("/2fa/disable", handler.DisableUserTwoFactor)
+		user.GET("/sessions", handler.ListUserSessions)
+		user.DELETE("/sessions/:id", handler.RevokeUserSession)
+		user.DELETE("/sessions", handler.RevokeAllUserSessions)
+		user.POST("/password/change", handler.ChangeUserPassword)
+		user.DELETE("/account", handler.DeleteUserAccount)
+		user.GET("/export", handler.ExportUserData)
+		user.GET("/me", handler.GetUserProfile)
+		user.PATCH("/me", handler.UpdateUserProfile)
+		user.POST("/roles/seller", handler.AddSellerRole)
+		user.POST("/addresses", handler.CreateAddress)
+		user.GET("/addresses", handler.ListAddresses)
+		user.GET("/addresses/:id", handler.GetAddress)
+		user.PATCH("/addresses/:id", handler.UpdateAddress)
+		user.DELETE("/addresses/:id", handler.DeleteAddress)
+		user.POST("/cart/add", middleware.RequireVerifiedUser(verification, middleware.ActionAddToCart), handler.AddProductToCart)
+		user.PUT("/cart/edit", middleware.RequireVerifiedUser(verification, middleware.ActionAddToCart), handler.EditCart)
 		user.DELETE("/cart/delete/:id", handler.DeleteProductFromCart)
 		user.GET("/order/view", handler.ViewOrders)
 		user.GET("/cart/view", handler.ViewCart)
-		user.POST("/placeorder", handler.PlaceOrder)
+		user.GET("/notifications", handler.ListUserNotifications)
+		user.PATCH("/notifications/read", handler.MarkAllUserNotificationsRead)
+		user.PATCH("/notifications/:id/read", handler.MarkUserNotificationRead)
+		user.GET("/history", handler.ViewHistory)
+		user.DELETE("/history", handler.ClearHistory)
+		user.GET("/wishlist", handler.ViewWishlist)
+		user.POST("/wishlist", handler.AddToWishlist)
+		user.DELETE("/wishlist/:id", handler.RemoveFromWishlist)
+		user.POST("/wishlist/:id/move", middleware.RequireVerifiedUser(verification, middleware.ActionAddToCart), handler.MoveWishlistItemToCart)
+		user.POST("/wishlist/share", handler.ShareWishlist)
+		user.DELETE("/wishlist/share", handler.UnshareWishlist)
+		user.POST("/placeorder", middleware.RequireVerifiedUser(verification, middleware.ActionPlaceOrder), handler.PlaceOrder)
 	}
 
 	// AuthorizeSeller authorizes all the authorized users haldlers
@@ -52,15 +100,70 @@ func SetupRouter(handler *api.HTTPHandler, repository ports.Repository) *gin.Eng
 	{
 		seller.POST("/create", handler.CreateSeller)
 		seller.POST("/login", handler.LoginSeller)
-		seller.DELETE("/clear", handler.ClearAll)
+		seller.POST("/login/2fa", handler.LoginSellerTwoFactor)
+		seller.POST("/token/refresh", handler.RefreshSellerToken)
+		seller.POST("/password/forgot", handler.ForgotSellerPassword)
+		seller.POST("/password/reset", handler.ResetSellerPassword)
+		seller.POST("/email/verify", handler.VerifySellerEmail)
+		seller.POST("/email/resend", handler.ResendSellerVerification)
+	}
+	authorizeSeller := middleware.AuthorizeSeller(handler.Keys, repository.GetSellerByAccountID, repository.TokenInBlacklist, repository.TouchSession)
+	seller.Use(middleware.AuthorizeSellerOrAPIKey(repository.FindAPIKeyByHash, repository.GetSellerByID, repository.TouchAPIKey, authorizeSeller))
+	{
+		seller.POST("/logout", middleware.RequireSession(), handler.Logout)
+		seller.POST("/2fa/enroll", middleware.RequireSession(), handler.EnrollSellerTwoFactor)
+		seller.POST("/2fa/confirm", middleware.RequireSession(), handler.ConfirmSellerTwoFactor)
+		seller.POST("/2fa/disable", middleware.RequireSession(), handler.DisableSellerTwoFactor)
+		seller.GET("/sessions", middleware.RequireSession(), handler.ListSellerSessions)
+		seller.DELETE("/sessions/:id", middleware.RequireSession(), handler.RevokeSellerSession)
+		seller.DELETE("/sessions", middleware.RequireSession(), handler.RevokeAllSellerSessions)
+		seller.POST("/password/change", middleware.RequireSession(), handler.ChangeSellerPassword)
+		seller.DELETE("/account", middleware.RequireSession(), handler.DeleteSellerAccount)
+		seller.GET("/export", middleware.RequireSession(), handler.ExportSellerData)
+		seller.GET("/me", middleware.RequireSession(), handler.GetSellerProfile)
+		seller.PATCH("/me", middleware.RequireSession(), handler.UpdateSellerProfile)
+		seller.POST("/roles/user", middleware.RequireSession(), handler.AddUserRole)
+		seller.GET("/notifications", middleware.RequireSession(), handler.ListSellerNotifications)
+		seller.PATCH("/notifications/read", middleware.RequireSession(), handler.MarkAllSellerNotificationsRead)
+		seller.PATCH("/notifications/:id/read", middleware.RequireSession(), handler.MarkSellerNotificationRead)
+		seller.POST("/apikeys", middleware.RequireSession(), handler.CreateAPIKey)
+		seller.GET("/apikeys", middleware.RequireSession(), handler.ListAPIKeys)
+		seller.DELETE("/apikeys/:id", middleware.RequireSession(), handler.RevokeAPIKey)
+		seller.POST("/product/add", middleware.RequireScope(models.ScopeProductsWrite), middleware.RequireVerifiedSeller(verification, middleware.ActionCreateProduct), handler.CreateProduct)
+		seller.GET("/products", middleware.RequireScope(models.ScopeProductsRead), handler.ListSellerProducts)
+		seller.PATCH("/product/:id", middleware.RequireScope(models.ScopeProductsWrite), handler.UpdateProduct)
+		seller.PATCH("/product/:id/status", middleware.RequireScope(models.ScopeProductsWrite), middleware.RequireVerifiedSeller(verification, middleware.ActionCreateProduct), handler.SetProductStatus)
+		seller.DELETE("/product/:id", middleware.RequireScope(models.ScopeProductsWrite), handler.DeleteProduct)
+		seller.GET("/orders/list", middleware.RequireScope(models.ScopeOrdersRead), handler.ListOrders)
+		seller.PATCH("/order/accept/:id", middleware.RequireScope(models.ScopeOrdersWrite), middleware.RequireVerifiedSeller(verification, middleware.ActionManageOrders), handler.AcceptOrder)
+		seller.PATCH("/order/decline/:id", middleware.RequireScope(models.ScopeOrdersWrite), middleware.RequireVerifiedSeller(verification, middleware.ActionManageOrders), handler.DeclineOrder)
+		seller.PATCH("/order/complete/:id", middleware.RequireScope(models.ScopeOrdersWrite), middleware.RequireVerifiedSeller(verification, middleware.ActionManageOrders), handler.CompleteOrder)
+		seller.GET("/reviews", middleware.RequireScope(models.ScopeProductsRead), handler.ListSellerReviews)
+		seller.POST("/reviews/:id/response", middleware.RequireScope(models.ScopeProductsWrite), handler.RespondToReview)
+	}
+
+	admin := r.Group("/admin")
+	{
+		admin.POST("/login", handler.LoginAdmin)
+		admin.POST("/login/2fa", handler.LoginAdminTwoFactor)
+		admin.POST("/token/refresh", handler.RefreshAdminToken)
 	}
-	seller.Use(middleware.AuthorizeSeller(repository.FindSellerByEmail, repository.TokenInBlacklist))
+	admin.Use(middleware.AuthorizeAdmin(handler.Keys, repository.GetAdminByAccountID, repository.TokenInBlacklist, repository.TouchSession))
 	{
-		seller.POST("/logout", handler.Logout)
-		seller.POST("/product/add", handler.CreateProduct)
-		seller.GET("/orders/list", handler.ListOrders)
-		seller.PATCH("/order/accept/:id", handler.AcceptOrder)
-		seller.PATCH("/order/decline/:id", handler.DeclineOrder)
+		admin.POST("/logout", handler.Logout)
+		admin.POST("/2fa/enroll", handler.EnrollAdminTwoFactor)
+		admin.POST("/2fa/confirm", handler.ConfirmAdminTwoFactor)
+		admin.POST("/2fa/disable", handler.DisableAdminTwoFactor)
+		admin.GET("/sessions", handler.ListAdminSessions)
+		admin.DELETE("/sessions/:id", handler.RevokeAdminSession)
+		admin.DELETE("/sessions", handler.RevokeAllAdminSessions)
+		admin.POST("/password/change", handler.ChangeAdminPassword)
+		admin.POST("/create", handler.CreateAdmin)
+		admin.GET("/lockouts", handler.ListAccountLockouts)
+		admin.POST("/categories", handler.CreateCategory)
+		admin.PATCH("/categories/:id", handler.UpdateCategory)
+		admin.DELETE("/categories/:id", handler.DeleteCategory)
+		admin.DELETE("/clear", handler.ClearAll)
 	}
 
 	return router
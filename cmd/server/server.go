@@ -3,6 +3,8 @@ package server
 import (
 	"context"
 	"e-commerce/internal/api"
+	"e-commerce/internal/mailer"
+	"e-commerce/internal/middleware"
 	"e-commerce/internal/repository"
 	"fmt"
 	"log"
@@ -20,11 +22,40 @@ func Run(db *gorm.DB, port string) {
 	//Create a new instance of our repository
 	newRepo := repository.NewDB(db)
 
+	//Make sure there is an admin to manage the platform
+	bootstrapAdmin(newRepo)
+
+	//Load the keys used to sign and verify tokens
+	keys, err := middleware.LoadKeySet()
+	if err != nil {
+		log.Fatalf("load signing keys: %s\n", err)
+	}
+
 	//Create a new instance of our handler
-	Handler := api.NewHTTPHandler(newRepo)
+	Handler := api.NewHTTPHandler(newRepo, keys, mailer.New())
+	Handler.PasswordPolicy, err = api.LoadPasswordPolicy()
+	if err != nil {
+		log.Fatalf("load password policy: %s\n", err)
+	}
+	Handler.LowStockThreshold, err = api.LoadLowStockThreshold()
+	if err != nil {
+		log.Fatalf("load low stock threshold: %s\n", err)
+	}
 	//Create a new router
 	router := SetupRouter(Handler, newRepo)
 
+	//Start background housekeeping
+	ctx, stopHousekeeping := context.WithCancel(context.Background())
+	defer stopHousekeeping()
+	go runPeriodically(ctx, "purge expired blacklist tokens", durationFromEnv("BLACKLIST_SWEEP_INTERVAL", 10*time.Minute), newRepo.PurgeExpiredBlacklistTokens)
+	go runPeriodically(ctx, "purge stale login throttles", durationFromEnv("LOGIN_THROTTLE_SWEEP_INTERVAL", 10*time.Minute), func() (int64, error) {
+		return newRepo.PurgeLoginThrottles(Handler.LoginPolicy.FailureWindow)
+	})
+	historyRetention := durationFromEnv("HISTORY_RETENTION", 90*24*time.Hour)
+	go runPeriodically(ctx, "purge old product views", durationFromEnv("HISTORY_SWEEP_INTERVAL", time.Hour), func() (int64, error) {
+		return newRepo.PurgeProductViews(time.Now().Add(-historyRetention))
+	})
+
 	//Create a new server
 	srv := &http.Server{
 		Addr:    ":" + port,
@@ -39,17 +70,17 @@ func Run(db *gorm.DB, port string) {
 		}
 	}()
 
-	sigChan := make(chan os.Signal)
+	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
 	signal.Notify(sigChan, os.Kill)
 
 	sig := <-sigChan
 	log.Println("Receive terminate and shutdown gracefully", sig)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err := srv.Shutdown(ctx)
+	err = srv.Shutdown(shutdownCtx)
 	if err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// runPeriodically calls task every interval until ctx is cancelled.
+// It is used for housekeeping such as purging expired rows.
+func runPeriodically(ctx context.Context, name string, interval time.Duration, task func() (int64, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := task()
+			if err != nil {
+				log.Printf("%s: %v\n", name, err)
+				continue
+			}
+			if removed > 0 {
+				log.Printf("%s: removed %d rows\n", name, removed)
+			}
+		}
+	}
+}
+
+// durationFromEnv reads a time.Duration such as "10m" from the environment
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("invalid %s %q, using %s\n", key, value, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// account gives the account flows (tokens, sessions, verification, two-factor)
+// uniform access to an Account signed in under one of its roles
+type account struct {
+	Role          models.Role
+	ID            uint
+	Email         string
+	FirstName     string
+	PasswordHash  string
+	EmailVerified bool
+	TokenVersion  uint
+	TwoFactor     *models.TwoFactor
+	// Model is the role's membership: the loaded *models.User, *models.Seller or *models.Admin
+	Model interface{}
+	// Save persists changes made to the account's TwoFactor
+	Save func() error
+}
+
+func (u *HTTPHandler) newAccount(role models.Role, identity *models.Account, membership interface{}) *account {
+	return &account{
+		Role:          role,
+		ID:            identity.ID,
+		Email:         identity.Email,
+		FirstName:     identity.FirstName,
+		PasswordHash:  identity.Password,
+		EmailVerified: identity.EmailVerifiedAt != nil,
+		TokenVersion:  identity.TokenVersion,
+		TwoFactor:     &identity.TwoFactor,
+		Model:         membership,
+		Save:          func() error { return u.Repository.UpdateAccount(identity) },
+	}
+}
+
+// response is the serializable view of the account's membership
+func (a *account) response() interface{} {
+	switch model := a.Model.(type) {
+	case *models.User:
+		return models.NewUserResponse(model)
+	case *models.Seller:
+		return models.NewSellerResponse(model)
+	case *models.Admin:
+		return models.NewAdminResponse(model)
+	}
+	return nil
+}
+
+func (u *HTTPHandler) userAccount(user *models.User) *account {
+	return u.newAccount(models.RoleUser, user.Account, user)
+}
+
+func (u *HTTPHandler) sellerAccount(seller *models.Seller) *account {
+	return u.newAccount(models.RoleSeller, seller.Account, seller)
+}
+
+func (u *HTTPHandler) adminAccount(admin *models.Admin) *account {
+	return u.newAccount(models.RoleAdmin, admin.Account, admin)
+}
+
+// accountFromContext returns the authenticated user, seller or admin
+func (u *HTTPHandler) accountFromContext(c *gin.Context, role models.Role) (*account, error) {
+	switch role {
+	case models.RoleSeller:
+		seller, err := u.GetSellerFromContext(c)
+		if err != nil {
+			return nil, err
+		}
+		return u.sellerAccount(seller), nil
+	case models.RoleAdmin:
+		admin, err := u.GetAdminFromContext(c)
+		if err != nil {
+			return nil, err
+		}
+		return u.adminAccount(admin), nil
+	}
+
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+	return u.userAccount(user), nil
+}
+
+// accountByID loads an account by ID, failing unless it holds a membership in role
+func (u *HTTPHandler) accountByID(role models.Role, accountID uint) (*account, error) {
+	switch role {
+	case models.RoleSeller:
+		seller, err := u.Repository.GetSellerByAccountID(accountID)
+		if err != nil {
+			return nil, err
+		}
+		return u.sellerAccount(seller), nil
+	case models.RoleAdmin:
+		admin, err := u.Repository.GetAdminByAccountID(accountID)
+		if err != nil {
+			return nil, err
+		}
+		return u.adminAccount(admin), nil
+	}
+
+	user, err := u.Repository.GetUserByAccountID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return u.userAccount(user), nil
+}
+
+// accountByEmail looks up an account by email, failing unless it holds a membership in role
+func (u *HTTPHandler) accountByEmail(role models.Role, email string) (*account, error) {
+	switch role {
+	case models.RoleSeller:
+		seller, err := u.Repository.FindSellerByEmail(email)
+		if err != nil {
+			return nil, err
+		}
+		return u.sellerAccount(seller), nil
+	case models.RoleAdmin:
+		admin, err := u.Repository.FindAdminByEmail(email)
+		if err != nil {
+			return nil, err
+		}
+		return u.adminAccount(admin), nil
+	}
+
+	user, err := u.Repository.FindUserByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	return u.userAccount(user), nil
+}
+
+// signupAccount returns a new, unsaved account for a signup. It responds and returns
+// false if the password is rejected. An email that already has an account yields nil:
+// the caller answers exactly as for a fresh signup, so signing up reveals neither the
+// account nor whether a password matches it, and the owner is emailed instead. A role
+// is added to an existing account only from a signed-in session, see AddSellerRole
+// and AddUserRole.
+func (u *HTTPHandler) signupAccount(c *gin.Context, role models.Role, request *models.SignupRequest) (*models.Account, bool) {
+	if problems := u.PasswordPolicy.Validate(request.Password); problems != nil {
+		util.Response(c, "Password does not meet the password policy", 400, nil, problems)
+		return nil, false
+	}
+
+	// Hash the password
+	hashedPassword, err := util.HashPassword(request.Password)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return nil, false
+	}
+
+	if identity, err := u.Repository.FindAccountByEmail(request.Email); err == nil {
+		if err := u.sendAccountExistsEmail(role, identity); err != nil {
+			log.Printf("send account exists email errors: %v\n", err)
+		}
+		return nil, true
+	}
+	return request.NewAccount(hashedPassword), true
+}
+
+// sendAccountExistsEmail tells the owner of an account that someone signed up with its email
+func (u *HTTPHandler) sendAccountExistsEmail(role models.Role, identity *models.Account) error {
+	return u.Mailer.Send(&models.MailMessage{
+		To:      identity.Email,
+		Subject: "You already have an account",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone tried to sign up as a %s with this email address, which already has an account. "+
+			"If it was you, sign in with your existing password; you can add the %s role to your account once signed in. "+
+			"If you forgot your password, you can reset it. If it was not you, you can ignore this email.\n",
+			identity.FirstName, role, role),
+	})
+}
+
+// setAccountPassword stores a new password hash on an account
+func (u *HTTPHandler) setAccountPassword(accountID uint, hashedPassword string) error {
+	identity, err := u.Repository.GetAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+	identity.Password = hashedPassword
+	return u.Repository.UpdateAccount(identity)
+}
+
+// markAccountVerified records that an account confirmed its email address
+func (u *HTTPHandler) markAccountVerified(accountID uint) error {
+	identity, err := u.Repository.GetAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+	if identity.EmailVerifiedAt == nil {
+		now := time.Now()
+		identity.EmailVerifiedAt = &now
+	}
+	return u.Repository.UpdateAccount(identity)
+}
+
+// appURL builds a link to the client application from APP_BASE_URL
+func appURL(path string) string {
+	base := os.Getenv("APP_BASE_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Change User password
+func (u *HTTPHandler) ChangeUserPassword(c *gin.Context) {
+	u.changePassword(c, models.RoleUser)
+}
+
+// Change Seller password
+func (u *HTTPHandler) ChangeSellerPassword(c *gin.Context) {
+	u.changePassword(c, models.RoleSeller)
+}
+
+// Change Admin password
+func (u *HTTPHandler) ChangeAdminPassword(c *gin.Context) {
+	u.changePassword(c, models.RoleAdmin)
+}
+
+// changePassword replaces the caller's password after checking the current one, then
+// signs every session out so the new password is needed everywhere
+func (u *HTTPHandler) changePassword(c *gin.Context, role models.Role) {
+	var request *models.ChangePasswordRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	account, err := u.accountFromContext(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(strings.TrimSpace(request.CurrentPassword))) != nil {
+		util.Response(c, "Current password is incorrect", 400, nil, nil)
+		return
+	}
+
+	request.NewPassword = strings.TrimSpace(request.NewPassword)
+	if problems := u.PasswordPolicy.Validate(request.NewPassword); problems != nil {
+		util.Response(c, "Password does not meet the password policy", 400, nil, problems)
+		return
+	}
+
+	reused, err := u.passwordReused(account.ID, account.PasswordHash, request.NewPassword)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	if reused {
+		util.Response(c, "Password does not meet the password policy", 400, nil, []string{
+			fmt.Sprintf("password must differ from your last %d passwords", u.PasswordPolicy.HistoryDepth),
+		})
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(request.NewPassword)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	if err := u.setAccountPassword(account.ID, hashedPassword); err != nil {
+		util.Response(c, "Error updating password", 500, err.Error(), nil)
+		return
+	}
+	u.recordPassword(account.ID, hashedPassword)
+
+	if err := u.Repository.RevokeAllSessions(account.ID); err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	if err := u.Repository.RevokeAccountAPIKeys(account.ID); err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Password changed, sign in again with your new password", 200, nil, nil)
+}
+
+// Delete User account
+func (u *HTTPHandler) DeleteUserAccount(c *gin.Context) {
+	u.deleteAccount(c, models.RoleUser)
+}
+
+// Delete Seller account
+func (u *HTTPHandler) DeleteSellerAccount(c *gin.Context) {
+	u.deleteAccount(c, models.RoleSeller)
+}
+
+// deleteAccount closes the caller's account in every role it holds. Personal data is
+// anonymised rather than deleted, so orders placed with or by the account stay intact.
+func (u *HTTPHandler) deleteAccount(c *gin.Context, role models.Role) {
+	var request *models.DeleteAccountRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	account, err := u.accountFromContext(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(strings.TrimSpace(request.Password))) != nil {
+		util.Response(c, "Password is incorrect", 400, nil, nil)
+		return
+	}
+
+	if err := u.Repository.DeleteAccount(account.ID); err != nil {
+		util.Response(c, "Error deleting account", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Account deleted", 200, nil, nil)
+}
+
+// Export everything stored about the User
+func (u *HTTPHandler) ExportUserData(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	addresses, err := u.Repository.ListAddresses(user.ID)
+	if err != nil {
+		util.Response(c, "Error exporting addresses", 500, err.Error(), nil)
+		return
+	}
+
+	cart, err := u.Repository.ListCartItems(user.ID)
+	if err != nil {
+		util.Response(c, "Error exporting cart", 500, err.Error(), nil)
+		return
+	}
+
+	wishlist, err := u.Repository.GetWishlist(user.ID)
+	if err != nil {
+		util.Response(c, "Error exporting wishlist", 500, err.Error(), nil)
+		return
+	}
+
+	reviews, err := u.Repository.ListReviewsByUserID(user.ID)
+	if err != nil {
+		util.Response(c, "Error exporting reviews", 500, err.Error(), nil)
+		return
+	}
+
+	history, err := u.Repository.ListProductViews(user.ID)
+	if err != nil {
+		util.Response(c, "Error exporting history", 500, err.Error(), nil)
+		return
+	}
+
+	orders, err := u.Repository.GetOrdersByUserID(user.ID)
+	if err != nil {
+		util.Response(c, "Error exporting orders", 500, err.Error(), nil)
+		return
+	}
+	for _, order := range orders {
+		if order.Items, err = u.Repository.GetOrderItemsByOrderID(order.ID); err != nil {
+			util.Response(c, "Error exporting orders", 500, err.Error(), nil)
+			return
+		}
+	}
+
+	sessions, err := u.Repository.ListSessions(user.AccountID)
+	if err != nil {
+		util.Response(c, "Error exporting sessions", 500, err.Error(), nil)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=user-data.json")
+	util.Response(c, "Data exported", 200, models.UserDataExport{
+		ExportedAt: time.Now(),
+		Profile:    models.NewUserResponse(user),
+		Addresses:  models.NewAddressResponses(addresses),
+		Cart:       models.NewCartEntryResponses(cart),
+		Wishlist:   models.NewWishlistResponse(wishlist),
+		Reviews:    models.NewReviewResponses(reviews),
+		History:    models.NewHistoryResponses(history),
+		Orders:     models.NewOrderResponses(orders),
+		Sessions:   models.NewSessionResponses(sessions, ""),
+	}, nil)
+}
+
+// Export everything stored about the Seller
+func (u *HTTPHandler) ExportSellerData(c *gin.Context) {
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting seller from context", 500, err.Error(), nil)
+		return
+	}
+
+	var products []models.Product
+	if err := u.Repository.GetProductsBySellerID(seller.ID, &products); err != nil {
+		util.Response(c, "Error exporting products", 500, err.Error(), nil)
+		return
+	}
+
+	orders, err := u.sellerOrders(seller.ID)
+	if err != nil {
+		util.Response(c, "Error exporting orders", 500, err.Error(), nil)
+		return
+	}
+
+	sessions, err := u.Repository.ListSessions(seller.AccountID)
+	if err != nil {
+		util.Response(c, "Error exporting sessions", 500, err.Error(), nil)
+		return
+	}
+
+	apiKeys, err := u.Repository.ListAPIKeys(seller.ID)
+	if err != nil {
+		util.Response(c, "Error exporting API keys", 500, err.Error(), nil)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=seller-data.json")
+	util.Response(c, "Data exported", 200, models.SellerDataExport{
+		ExportedAt: time.Now(),
+		Profile:    models.NewSellerResponse(seller),
+		Products:   models.NewProductResponses(products),
+		Orders:     models.NewOrderResponses(orders),
+		Sessions:   models.NewSessionResponses(sessions, ""),
+		APIKeys:    models.NewAPIKeyResponses(apiKeys),
+	}, nil)
+}
+
+// Get the User's profile
+func (u *HTTPHandler) GetUserProfile(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Profile fetched", 200, models.NewUserResponse(user), nil)
+}
+
+// Update the User's profile. Only the fields present in the body change.
+func (u *HTTPHandler) UpdateUserProfile(c *gin.Context) {
+	var request *models.UpdateProfileRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	request.Apply(user.Account)
+	if err := u.Repository.UpdateUser(user); err != nil {
+		util.Response(c, "Error updating profile", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Profile updated", 200, models.NewUserResponse(user), nil)
+}
+
+// Get the Seller's profile
+func (u *HTTPHandler) GetSellerProfile(c *gin.Context) {
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting seller from context", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Profile fetched", 200, models.NewSellerResponse(seller), nil)
+}
+
+// Update the Seller's profile. Only the fields present in the body change.
+func (u *HTTPHandler) UpdateSellerProfile(c *gin.Context) {
+	var request *models.UpdateSellerProfileRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting seller from context", 500, err.Error(), nil)
+		return
+	}
+
+	if request.StoreName != nil && strings.TrimSpace(*request.StoreName) == "" {
+		util.Response(c, "Store name must not be empty", 400, nil, nil)
+		return
+	}
+
+	request.Apply(seller)
+	if err := u.Repository.UpdateSeller(seller); err != nil {
+		util.Response(c, "Error updating profile", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Profile updated", 200, models.NewSellerResponse(seller), nil)
+}
+
+// Add the seller role to the signed-in User's account
+func (u *HTTPHandler) AddSellerRole(c *gin.Context) {
+	var request *models.AddSellerRoleRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	if _, err := u.Repository.GetSellerByAccountID(user.AccountID); err == nil {
+		util.Response(c, "Your account is already a seller", 400, nil, nil)
+		return
+	}
+
+	seller := &models.Seller{
+		AccountID:     user.AccountID,
+		Account:       user.Account,
+		StoreName:     request.StoreName,
+		StoreCategory: request.StoreCategory,
+	}
+	if err := u.Repository.CreateSeller(seller); err != nil {
+		util.Response(c, "Seller not created", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Seller role added, sign in as a seller to use it", 200, models.NewSellerResponse(seller), nil)
+}
+
+// Add the buyer role to the signed-in Seller's account
+func (u *HTTPHandler) AddUserRole(c *gin.Context) {
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting seller from context", 500, err.Error(), nil)
+		return
+	}
+
+	if _, err := u.Repository.GetUserByAccountID(seller.AccountID); err == nil {
+		util.Response(c, "Your account is already a buyer", 400, nil, nil)
+		return
+	}
+
+	user := &models.User{
+		AccountID: seller.AccountID,
+		Account:   seller.Account,
+	}
+	if err := u.Repository.CreateUser(user); err != nil {
+		util.Response(c, "User not created", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Buyer role added, sign in as a user to use it", 200, models.NewUserResponse(user), nil)
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// add an address to the address book
+func (u *HTTPHandler) CreateAddress(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	var request *models.CreateAddressRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	address := request.NewAddress(user.ID)
+	if err := u.Repository.CreateAddress(address); err != nil {
+		util.Response(c, "Address not created", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Address created", 200, models.NewAddressResponse(address), nil)
+}
+
+// list the address book
+func (u *HTTPHandler) ListAddresses(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	addresses, err := u.Repository.ListAddresses(user.ID)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Addresses fetched", 200, gin.H{
+		"addresses": models.NewAddressResponses(addresses),
+	}, nil)
+}
+
+// get one address
+func (u *HTTPHandler) GetAddress(c *gin.Context) {
+	address, ok := u.ownAddress(c)
+	if !ok {
+		return
+	}
+
+	util.Response(c, "Address fetched", 200, models.NewAddressResponse(address), nil)
+}
+
+// update an address. Only the fields present in the body change.
+func (u *HTTPHandler) UpdateAddress(c *gin.Context) {
+	var request *models.UpdateAddressRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	address, ok := u.ownAddress(c)
+	if !ok {
+		return
+	}
+
+	// The default can move to another address but not simply be switched off
+	if request.IsDefault != nil && !*request.IsDefault && address.IsDefault {
+		util.Response(c, "Make another address the default instead", 400, nil, nil)
+		return
+	}
+
+	request.Apply(address)
+	if err := u.Repository.UpdateAddress(address); err != nil {
+		util.Response(c, "Error updating address", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Address updated", 200, models.NewAddressResponse(address), nil)
+}
+
+// delete an address. Orders already placed keep their own copy of it.
+func (u *HTTPHandler) DeleteAddress(c *gin.Context) {
+	address, ok := u.ownAddress(c)
+	if !ok {
+		return
+	}
+
+	if err := u.Repository.DeleteAddress(address); err != nil {
+		util.Response(c, "Error deleting address", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Address deleted", 200, nil, nil)
+}
+
+// ownAddress loads the address named in the path, answering 404 if it belongs to someone else
+func (u *HTTPHandler) ownAddress(c *gin.Context) (*models.Address, bool) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return nil, false
+	}
+
+	addressID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid address ID", 400, err.Error(), nil)
+		return nil, false
+	}
+
+	address, err := u.Repository.GetAddressByID(addressID)
+	if err != nil || address.UserID != user.ID {
+		util.Response(c, "Address not found", 404, nil, nil)
+		return nil, false
+	}
+	return address, true
+}
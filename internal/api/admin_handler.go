@@ -0,0 +1,159 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Login Admin
+func (u *HTTPHandler) LoginAdmin(c *gin.Context) {
+	var loginRequest *models.LoginRequestAdmin
+	err := c.ShouldBind(&loginRequest)
+	if err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	loginRequest.Email = strings.TrimSpace(loginRequest.Email)
+	loginRequest.Password = strings.TrimSpace(loginRequest.Password)
+
+	if loginRequest.Email == "" {
+		util.Response(c, "Email must not be empty", 400, nil, nil)
+		return
+	}
+	if loginRequest.Password == "" {
+		util.Response(c, "Password must not be empty", 400, nil, nil)
+		return
+	}
+
+	if !u.loginAllowed(c, loginRequest.Email) {
+		return
+	}
+
+	admin, err := u.Repository.FindAdminByEmail(loginRequest.Email)
+	if err != nil {
+		compareDummyPassword(loginRequest.Password)
+		u.loginFailed(c, models.RoleAdmin, loginRequest.Email)
+		util.Response(c, invalidCredentials, 401, nil, nil)
+		return
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(admin.Account.Password), []byte(loginRequest.Password))
+	if err != nil {
+		u.loginFailed(c, models.RoleAdmin, loginRequest.Email)
+		util.Response(c, invalidCredentials, 401, nil, nil)
+		return
+	}
+	rehashPassword(&admin.Account.Password, loginRequest.Password, u.adminAccount(admin).Save)
+
+	// With two-factor enabled the password only earns a challenge token
+	if admin.Account.TOTPEnabled {
+		u.requireSecondFactor(c, models.RoleAdmin, admin.AccountID)
+		return
+	}
+	u.loginSucceeded(loginRequest.Email)
+
+	accessToken, refreshToken, err := u.startSession(c, u.adminAccount(admin))
+	if err != nil {
+		util.Response(c, "Error generating tokens", 500, err.Error(), nil)
+		return
+	}
+
+	c.Header("access_token", *accessToken)
+	c.Header("refresh_token", *refreshToken)
+
+	util.Response(c, "Login successful", 200, gin.H{
+		"admin":         models.NewAdminResponse(admin),
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	}, nil)
+}
+
+// Create another Admin, or grant admin rights to an existing account. Only an
+// authenticated admin can do this.
+func (u *HTTPHandler) CreateAdmin(c *gin.Context) {
+	var request *models.CreateAdminRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	if _, err := u.Repository.FindAdminByEmail(request.Email); err == nil {
+		util.Response(c, "Admin already exists", 400, "Bad request body", nil)
+		return
+	}
+
+	if identity, err := u.Repository.FindAccountByEmail(request.Email); err == nil {
+		admin := &models.Admin{AccountID: identity.ID, Account: identity}
+		if err := u.Repository.CreateAdmin(admin); err != nil {
+			util.Response(c, "Admin not created", 500, err.Error(), nil)
+			return
+		}
+		util.Response(c, "Admin rights granted to existing account", 200, models.NewAdminResponse(admin), nil)
+		return
+	}
+
+	if problems := u.PasswordPolicy.Validate(request.Password); problems != nil {
+		util.Response(c, "Password does not meet the password policy", 400, nil, problems)
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(request.Password)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	// The inviting admin vouches for the address, so there is no verification step
+	now := time.Now()
+	admin := &models.Admin{
+		Account: &models.Account{
+			FirstName:       request.FirstName,
+			LastName:        request.LastName,
+			Email:           request.Email,
+			Password:        hashedPassword,
+			EmailVerifiedAt: &now,
+		},
+	}
+
+	if err := u.Repository.CreateAdmin(admin); err != nil {
+		util.Response(c, "Admin not created", 500, err.Error(), nil)
+		return
+	}
+	u.recordPassword(admin.AccountID, hashedPassword)
+
+	util.Response(c, "Admin created", 200, models.NewAdminResponse(admin), nil)
+}
+
+// list account lockouts caused by repeated failed logins
+func (u *HTTPHandler) ListAccountLockouts(c *gin.Context) {
+	lockouts, err := u.Repository.ListAccountLockouts()
+	if err != nil {
+		util.Response(c, "Error getting lockouts", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Lockouts fetched", 200, gin.H{
+		"lockouts": models.NewAccountLockoutResponses(lockouts),
+	}, nil)
+}
+
+// clear all products, orders. Only available when the server runs in test mode.
+func (u *HTTPHandler) ClearAll(c *gin.Context) {
+	if !u.TestMode {
+		util.Response(c, "Clearing all data is only available in test mode", 403, nil, nil)
+		return
+	}
+
+	err := u.Repository.ClearAll()
+	if err != nil {
+		util.Response(c, "Error clearing all data", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "All data cleared", 200, nil, nil)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// create an API key
+func (u *HTTPHandler) CreateAPIKey(c *gin.Context) {
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Invalid token", 401, err.Error(), nil)
+		return
+	}
+
+	var request *models.CreateAPIKeyRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	// Check every requested scope is one we know about
+	known := make(map[string]bool)
+	for _, scope := range models.APIKeyScopes {
+		known[scope] = true
+	}
+	scopes := make([]string, 0, len(request.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range request.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !known[scope] {
+			util.Response(c, "Unknown scope", 400, scope, nil)
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	secret, err := util.GenerateRandomToken()
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	rawKey := "sk_" + secret
+
+	key := &models.APIKey{
+		SellerID: seller.ID,
+		Name:     strings.TrimSpace(request.Name),
+		Prefix:   rawKey[:10],
+		KeyHash:  util.HashToken(rawKey),
+		Scopes:   strings.Join(scopes, ","),
+	}
+	if request.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, request.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := u.Repository.CreateAPIKey(key); err != nil {
+		util.Response(c, "API key not created", 500, err.Error(), nil)
+		return
+	}
+
+	response := models.NewAPIKeyResponse(key)
+	response.Key = rawKey
+	util.Response(c, "API key created. Copy it now, it will not be shown again", 200, response, nil)
+}
+
+// list API keys
+func (u *HTTPHandler) ListAPIKeys(c *gin.Context) {
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Invalid token", 401, err.Error(), nil)
+		return
+	}
+
+	keys, err := u.Repository.ListAPIKeys(seller.ID)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "API keys fetched", 200, gin.H{
+		"api_keys": models.NewAPIKeyResponses(keys),
+	}, nil)
+}
+
+// revoke an API key
+func (u *HTTPHandler) RevokeAPIKey(c *gin.Context) {
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Invalid token", 401, err.Error(), nil)
+		return
+	}
+
+	keyID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid API key ID", 400, err.Error(), nil)
+		return
+	}
+
+	key, err := u.Repository.GetAPIKeyByID(keyID)
+	if err != nil || key.SellerID != seller.ID || key.RevokedAt != nil {
+		util.Response(c, "API key not found", 404, nil, nil)
+		return
+	}
+
+	if err := u.Repository.RevokeAPIKey(key); err != nil {
+		util.Response(c, "Error revoking API key", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "API key revoked", 200, nil, nil)
+}
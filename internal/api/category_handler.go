@@ -0,0 +1,222 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// list the category tree with the number of products on sale in each category,
+// descendants included
+func (u *HTTPHandler) ListCategories(c *gin.Context) {
+	categories, err := u.Repository.ListCategories()
+	if err != nil {
+		util.Response(c, "Error fetching categories", 500, err.Error(), nil)
+		return
+	}
+	counts, err := u.Repository.CountProductsByCategory(true)
+	if err != nil {
+		util.Response(c, "Error fetching categories", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Categories fetched", 200, models.NewCategoryTree(categories, counts), nil)
+}
+
+// list the products in a category and its descendants. The catalogue filters, sort and
+// page parameters apply as well.
+func (u *HTTPHandler) ListCategoryProducts(c *gin.Context) {
+	category, ok := u.category(c)
+	if !ok {
+		return
+	}
+
+	query, ok := bindProductQuery(c, models.ProductSortNewest)
+	if !ok {
+		return
+	}
+	query.CategoryID = category.ID
+
+	products, total, err := u.Repository.ListProducts(query)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "Products fetched", 200, gin.H{
+		"category": models.NewCategoryResponse(category),
+		"products": models.NewProductResponses(products),
+		"meta":     models.NewPageMeta(total, query.Page, query.Limit),
+	}, nil)
+}
+
+// create a category, at the root or under parent_id
+func (u *HTTPHandler) CreateCategory(c *gin.Context) {
+	var request *models.CreateCategoryRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	category := &models.Category{Name: request.Name, Slug: request.Slug}
+	if category.Slug == "" {
+		category.Slug = request.Name
+	}
+	if request.ParentID != nil && *request.ParentID != 0 {
+		category.ParentID = request.ParentID
+	}
+
+	categories, err := u.Repository.ListCategories()
+	if err != nil {
+		util.Response(c, "Error creating category", 500, err.Error(), nil)
+		return
+	}
+	if !validateCategory(c, category, categories) {
+		return
+	}
+
+	if err := u.Repository.CreateCategory(category); err != nil {
+		if errors.Is(err, models.ErrCategorySlugTaken) {
+			util.Response(c, "Category slug already in use", 409, nil, nil)
+			return
+		}
+		util.Response(c, "Error creating category", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "Category created", 200, models.NewCategoryResponse(category), nil)
+}
+
+// rename a category or move it under another parent
+func (u *HTTPHandler) UpdateCategory(c *gin.Context) {
+	var request *models.UpdateCategoryRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	category, ok := u.category(c)
+	if !ok {
+		return
+	}
+
+	if request.Name != nil {
+		category.Name = *request.Name
+	}
+	if request.Slug != nil {
+		category.Slug = *request.Slug
+	}
+	if request.ParentID != nil {
+		category.ParentID = request.ParentID
+		if *request.ParentID == 0 {
+			category.ParentID = nil
+		}
+	}
+
+	categories, err := u.Repository.ListCategories()
+	if err != nil {
+		util.Response(c, "Error updating category", 500, err.Error(), nil)
+		return
+	}
+	if !validateCategory(c, category, categories) {
+		return
+	}
+
+	if err := u.Repository.UpdateCategory(category); err != nil {
+		if errors.Is(err, models.ErrCategorySlugTaken) {
+			util.Response(c, "Category slug already in use", 409, nil, nil)
+			return
+		}
+		util.Response(c, "Error updating category", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "Category updated", 200, models.NewCategoryResponse(category), nil)
+}
+
+// delete a category that has neither subcategories nor products
+func (u *HTTPHandler) DeleteCategory(c *gin.Context) {
+	category, ok := u.category(c)
+	if !ok {
+		return
+	}
+
+	categories, err := u.Repository.ListCategories()
+	if err != nil {
+		util.Response(c, "Error deleting category", 500, err.Error(), nil)
+		return
+	}
+	for _, other := range categories {
+		if other.ParentID != nil && *other.ParentID == category.ID {
+			util.Response(c, "Category has subcategories", 409, nil, nil)
+			return
+		}
+	}
+
+	counts, err := u.Repository.CountProductsByCategory(false)
+	if err != nil {
+		util.Response(c, "Error deleting category", 500, err.Error(), nil)
+		return
+	}
+	if counts[category.ID] > 0 {
+		util.Response(c, "Category has products", 409, nil, nil)
+		return
+	}
+
+	if err := u.Repository.DeleteCategory(category); err != nil {
+		util.Response(c, "Error deleting category", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "Category deleted", 200, nil, nil)
+}
+
+// category loads the category named by the :id path parameter
+func (u *HTTPHandler) category(c *gin.Context) (*models.Category, bool) {
+	categoryID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid category ID", 400, err.Error(), nil)
+		return nil, false
+	}
+
+	category, err := u.Repository.GetCategoryByID(categoryID)
+	if err != nil {
+		util.Response(c, "Category not found", 404, nil, nil)
+		return nil, false
+	}
+	return category, true
+}
+
+// categoryExists checks the category a seller files a product under
+func (u *HTTPHandler) categoryExists(c *gin.Context, categoryID uint) bool {
+	if _, err := u.Repository.GetCategoryByID(categoryID); err != nil {
+		util.Response(c, "Category not found", 400, nil, nil)
+		return false
+	}
+	return true
+}
+
+// validateCategory normalises the slug and checks that the parent exists and is not the
+// category itself or one of its descendants. Slug clashes are left to the unique index.
+func validateCategory(c *gin.Context, category *models.Category, categories []models.Category) bool {
+	category.Slug = models.Slugify(category.Slug)
+	if category.Slug == "" {
+		util.Response(c, "Category slug must contain letters or digits", 400, nil, nil)
+		return false
+	}
+
+	parentFound := category.ParentID == nil
+	for _, other := range categories {
+		if category.ParentID != nil && other.ID == *category.ParentID {
+			parentFound = true
+		}
+	}
+	if !parentFound {
+		util.Response(c, "Parent category not found", 400, nil, nil)
+		return false
+	}
+
+	if category.ID != 0 && category.ParentID != nil && models.IsDescendant(categories, category.ID, *category.ParentID) {
+		util.Response(c, "A category cannot be moved under itself or its subcategories", 400, nil, nil)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// view recently viewed products with their current price and stock
+func (u *HTTPHandler) ViewHistory(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	views, err := u.Repository.ListProductViews(user.ID)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "History fetched", 200, gin.H{
+		"history": models.NewHistoryResponses(views),
+	}, nil)
+}
+
+// clear the browsing history
+func (u *HTTPHandler) ClearHistory(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	if err := u.Repository.ClearProductViews(user.ID); err != nil {
+		util.Response(c, "Error clearing history", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "History cleared", 200, nil, nil)
+}
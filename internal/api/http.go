@@ -1,19 +1,37 @@
 package api
 
 import (
+	"e-commerce/internal/middleware"
 	"e-commerce/internal/models"
 	"e-commerce/internal/ports"
 	"fmt"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
 type HTTPHandler struct {
-	Repository ports.Repository
+	Repository     ports.Repository
+	Keys           *middleware.KeySet
+	Mailer         ports.Mailer
+	LoginPolicy    LoginPolicy
+	PasswordPolicy PasswordPolicy
+	// TestMode enables destructive operations such as ClearAll. It is on only when APP_ENV=test.
+	TestMode bool
+	// LowStockThreshold is the stock level at which sellers are notified
+	LowStockThreshold int
 }
 
-func NewHTTPHandler(repository ports.Repository) *HTTPHandler {
+func NewHTTPHandler(repository ports.Repository, keys *middleware.KeySet, mailer ports.Mailer) *HTTPHandler {
 	return &HTTPHandler{
-		Repository: repository,
+		Repository:        repository,
+		Keys:              keys,
+		Mailer:            mailer,
+		LoginPolicy:       DefaultLoginPolicy(),
+		PasswordPolicy:    DefaultPasswordPolicy(),
+		TestMode:          os.Getenv("APP_ENV") == "test",
+		LowStockThreshold: DefaultLowStockThreshold,
 	}
 }
 
@@ -41,6 +59,18 @@ func (u *HTTPHandler) GetSellerFromContext(c *gin.Context) (*models.Seller, erro
 	return seller, nil
 }
 
+func (u *HTTPHandler) GetAdminFromContext(c *gin.Context) (*models.Admin, error) {
+	contextAdmin, exists := c.Get("admin")
+	if !exists {
+		return nil, fmt.Errorf("error getting admin from context")
+	}
+	admin, ok := contextAdmin.(*models.Admin)
+	if !ok {
+		return nil, fmt.Errorf("an error occurred")
+	}
+	return admin, nil
+}
+
 func (u *HTTPHandler) GetTokenFromContext(c *gin.Context) (string, error) {
 	tokenI, exists := c.Get("access_token")
 	if !exists {
@@ -49,3 +79,15 @@ func (u *HTTPHandler) GetTokenFromContext(c *gin.Context) (string, error) {
 	tokenstr := tokenI.(string)
 	return tokenstr, nil
 }
+
+func (u *HTTPHandler) GetClaimsFromContext(c *gin.Context) (jwt.MapClaims, error) {
+	claimsI, exists := c.Get("access_claims")
+	if !exists {
+		return nil, fmt.Errorf("error getting access token claims")
+	}
+	claims, ok := claimsI.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("an error occurred")
+	}
+	return claims, nil
+}
@@ -0,0 +1,11 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes the public verification keys so other services can validate our tokens
+func (u *HTTPHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, u.Keys.JWKS())
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LoginPolicy controls how failed logins are slowed down and locked out.
+// Failures are counted separately per account email and per client IP.
+type LoginPolicy struct {
+	// FreeAttempts is how many failures are allowed before delays start
+	FreeAttempts int
+	// BaseDelay is the wait after the first delayed failure; it doubles with each further failure
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// AccountLockoutThreshold and IPLockoutThreshold are the failure counts that trigger a lockout
+	AccountLockoutThreshold int
+	IPLockoutThreshold      int
+	LockoutDuration         time.Duration
+	// FailureWindow is how long a failure is remembered
+	FailureWindow time.Duration
+}
+
+func DefaultLoginPolicy() LoginPolicy {
+	return LoginPolicy{
+		FreeAttempts:            3,
+		BaseDelay:               time.Second,
+		MaxDelay:                time.Minute,
+		AccountLockoutThreshold: 10,
+		IPLockoutThreshold:      50,
+		LockoutDuration:         time.Minute * 15,
+		FailureWindow:           time.Hour,
+	}
+}
+
+// invalidCredentials is the single response for an unknown email or a wrong password
+const invalidCredentials = "Invalid email or password"
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyPassword spends the same time as a real bcrypt compare, so a missing
+// account cannot be told apart from a wrong password by timing
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		hash, err := util.HashPassword("not-a-real-password")
+		if err != nil {
+			log.Printf("hash dummy password errors: %v\n", err)
+			return
+		}
+		dummyHash = []byte(hash)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// accountThrottleKey is shared by every role, since they all sign in to the same account
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// delayFor returns how long a key with the given number of failures must wait
+func (p LoginPolicy) delayFor(failures int) time.Duration {
+	if failures < p.FreeAttempts {
+		return 0
+	}
+	delay := time.Duration(float64(p.BaseDelay) * math.Pow(2, float64(failures-p.FreeAttempts)))
+	if delay > p.MaxDelay || delay <= 0 {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// loginAllowed checks the account and IP throttles before any password is compared.
+// It responds with 429 and returns false while either key is locked or waiting out a delay.
+func (u *HTTPHandler) loginAllowed(c *gin.Context, email string) bool {
+	now := time.Now()
+	var wait time.Duration
+
+	for _, key := range []string{accountThrottleKey(email), ipThrottleKey(c.ClientIP())} {
+		throttle, err := u.Repository.GetLoginThrottle(key)
+		if err != nil {
+			log.Printf("get login throttle errors: %v\n", err)
+			continue
+		}
+		if throttle == nil {
+			continue
+		}
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			wait = maxDuration(wait, throttle.LockedUntil.Sub(now))
+			continue
+		}
+		if now.Sub(throttle.LastFailureAt) > u.LoginPolicy.FailureWindow {
+			continue
+		}
+		if next := throttle.LastFailureAt.Add(u.LoginPolicy.delayFor(throttle.Failures)); next.After(now) {
+			wait = maxDuration(wait, next.Sub(now))
+		}
+	}
+
+	if wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		util.Response(c, "Too many failed login attempts, try again later", 429, nil, nil)
+		return false
+	}
+	return true
+}
+
+// loginFailed records a failed attempt against the account and the IP, locking either
+// out once it crosses its threshold
+func (u *HTTPHandler) loginFailed(c *gin.Context, role models.Role, email string) {
+	ip := c.ClientIP()
+	thresholds := map[string]int{
+		accountThrottleKey(email): u.LoginPolicy.AccountLockoutThreshold,
+		ipThrottleKey(ip):         u.LoginPolicy.IPLockoutThreshold,
+	}
+
+	for key, threshold := range thresholds {
+		throttle, err := u.Repository.RecordLoginFailure(key, u.LoginPolicy.FailureWindow)
+		if err != nil {
+			log.Printf("record login failure errors: %v\n", err)
+			continue
+		}
+		if throttle.Failures < threshold {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(u.LoginPolicy.LockoutDuration)
+		if err := u.Repository.LockLogin(key, lockedUntil); err != nil {
+			log.Printf("lock login errors: %v\n", err)
+			continue
+		}
+		err = u.Repository.CreateAccountLockout(&models.AccountLockout{
+			Key:         key,
+			Role:        role,
+			Email:       email,
+			IPAddress:   ip,
+			Failures:    throttle.Failures,
+			LockedUntil: lockedUntil,
+		})
+		if err != nil {
+			log.Printf("create account lockout errors: %v\n", err)
+		}
+	}
+}
+
+// loginSucceeded clears the account's failures. The IP counter is left to age out,
+// otherwise an attacker could reset it by logging into an account of their own.
+func (u *HTTPHandler) loginSucceeded(email string) {
+	if err := u.Repository.ClearLoginFailures(accountThrottleKey(email)); err != nil {
+		log.Printf("clear login failures errors: %v\n", err)
+	}
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
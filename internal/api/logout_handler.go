@@ -1,6 +1,7 @@
 package api
 
 import (
+	"e-commerce/internal/middleware"
 	"e-commerce/internal/models"
 	"e-commerce/internal/util"
 
@@ -10,13 +11,14 @@ import (
 func (u *HTTPHandler) Logout(c *gin.Context) {
 	blacklistTokens := &models.BlacklistTokens{}
 
-	// Extract the token from the request header
-	token, err := u.GetTokenFromContext(c)
+	// Extract the token claims set by the authorization middleware
+	claims, err := u.GetClaimsFromContext(c)
 	if err != nil {
 		util.Response(c, "Error getting token from header", 500, err.Error(), nil)
 		return
 	}
-	blacklistTokens.Token = token
+	blacklistTokens.TokenID, _ = claims["jti"].(string)
+	blacklistTokens.ExpiresAt = middleware.GetExpiry(claims)
 
 	// Blacklist the token
 	err = u.Repository.BlacklistToken(blacklistTokens)
@@ -24,6 +26,13 @@ func (u *HTTPHandler) Logout(c *gin.Context) {
 		util.Response(c, "Could not blacklist token", 500, err.Error(), nil)
 		return
 	}
+	// End the session so its refresh token cannot bring it back
+	if session, err := u.Repository.GetSessionByFamilyID(middleware.GetSessionID(claims)); err == nil {
+		if err := u.Repository.RevokeSession(session); err != nil {
+			util.Response(c, "Could not end session", 500, err.Error(), nil)
+			return
+		}
+	}
 	util.Response(c, "Logged out successfully", 200, nil, nil)
 
 }
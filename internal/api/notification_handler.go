@@ -0,0 +1,229 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultLowStockThreshold is the stock level at or below which a seller is warned
+	DefaultLowStockThreshold = 5
+	notificationPageSize     = 50
+)
+
+// LoadLowStockThreshold reads LOW_STOCK_THRESHOLD, falling back to the default
+func LoadLowStockThreshold() (int, error) {
+	value := os.Getenv("LOW_STOCK_THRESHOLD")
+	if value == "" {
+		return DefaultLowStockThreshold, nil
+	}
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < 0 {
+		return DefaultLowStockThreshold, fmt.Errorf("invalid LOW_STOCK_THRESHOLD %q", value)
+	}
+	return threshold, nil
+}
+
+// List User notifications
+func (u *HTTPHandler) ListUserNotifications(c *gin.Context) {
+	u.listNotifications(c, models.RoleUser)
+}
+
+// List Seller notifications
+func (u *HTTPHandler) ListSellerNotifications(c *gin.Context) {
+	u.listNotifications(c, models.RoleSeller)
+}
+
+// Mark one User notification read
+func (u *HTTPHandler) MarkUserNotificationRead(c *gin.Context) {
+	u.markNotificationRead(c, models.RoleUser)
+}
+
+// Mark one Seller notification read
+func (u *HTTPHandler) MarkSellerNotificationRead(c *gin.Context) {
+	u.markNotificationRead(c, models.RoleSeller)
+}
+
+// Mark every User notification read
+func (u *HTTPHandler) MarkAllUserNotificationsRead(c *gin.Context) {
+	u.markAllNotificationsRead(c, models.RoleUser)
+}
+
+// Mark every Seller notification read
+func (u *HTTPHandler) MarkAllSellerNotificationsRead(c *gin.Context) {
+	u.markAllNotificationsRead(c, models.RoleSeller)
+}
+
+// notificationRecipient returns the ID of the caller's membership in role, which is
+// what notifications are addressed to
+func (u *HTTPHandler) notificationRecipient(c *gin.Context, role models.Role) (uint, error) {
+	if role == models.RoleSeller {
+		seller, err := u.GetSellerFromContext(c)
+		if err != nil {
+			return 0, err
+		}
+		return seller.ID, nil
+	}
+
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// listNotifications returns the newest notifications; ?unread=true leaves out read ones
+func (u *HTTPHandler) listNotifications(c *gin.Context, role models.Role) {
+	recipientID, err := u.notificationRecipient(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+	notifications, err := u.Repository.ListNotifications(role, recipientID, unreadOnly, notificationPageSize)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	unread, err := u.Repository.CountUnreadNotifications(role, recipientID)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Notifications fetched", 200, gin.H{
+		"notifications": models.NewNotificationResponses(notifications),
+		"unread_count":  unread,
+	}, nil)
+}
+
+func (u *HTTPHandler) markNotificationRead(c *gin.Context, role models.Role) {
+	recipientID, err := u.notificationRecipient(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	notificationID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid notification ID", 400, err.Error(), nil)
+		return
+	}
+
+	err = u.Repository.MarkNotificationRead(role, recipientID, notificationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		util.Response(c, "Notification not found", 404, nil, nil)
+		return
+	}
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Notification marked read", 200, nil, nil)
+}
+
+func (u *HTTPHandler) markAllNotificationsRead(c *gin.Context, role models.Role) {
+	recipientID, err := u.notificationRecipient(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	count, err := u.Repository.MarkAllNotificationsRead(role, recipientID)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Notifications marked read", 200, gin.H{
+		"marked": count,
+	}, nil)
+}
+
+// notify saves notifications on a best-effort basis. The action that caused them has
+// already happened, so a failure is logged rather than reported to the caller.
+func (u *HTTPHandler) notify(notifications ...*models.Notification) {
+	if err := u.Repository.CreateNotifications(notifications); err != nil {
+		log.Printf("create notifications errors: %v\n", err)
+	}
+}
+
+// orderPlacedNotifications tells every seller with a product in the order about it,
+// and warns a seller whose product has just fallen to the low stock threshold
+func (u *HTTPHandler) orderPlacedNotifications(order *models.Order, products map[uint]*models.Product) []*models.Notification {
+	var notifications []*models.Notification
+	notified := make(map[uint]bool)
+
+	for _, item := range order.Items {
+		product := products[item.ProductID]
+		if product == nil {
+			continue
+		}
+
+		if !notified[product.SellerID] {
+			notified[product.SellerID] = true
+			notifications = append(notifications, &models.Notification{
+				Role:        models.RoleSeller,
+				RecipientID: product.SellerID,
+				Type:        models.NotificationOrderPlaced,
+				Title:       "New order",
+				Message:     fmt.Sprintf("Order #%d includes your products", order.ID),
+				OrderID:     &order.ID,
+			})
+		}
+
+		if notification := u.lowStockNotification(product, product.Quantity-item.Quantity); notification != nil {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications
+}
+
+// lowStockNotification warns the seller when stock crosses the threshold, not on every
+// sale below it
+func (u *HTTPHandler) lowStockNotification(product *models.Product, remaining int) *models.Notification {
+	if product.Quantity <= u.LowStockThreshold || remaining > u.LowStockThreshold {
+		return nil
+	}
+	productID := product.ID
+	return &models.Notification{
+		Role:        models.RoleSeller,
+		RecipientID: product.SellerID,
+		Type:        models.NotificationLowStock,
+		Title:       "Low stock",
+		Message:     fmt.Sprintf("%s has %d left in stock", product.Title, remaining),
+		ProductID:   &productID,
+	}
+}
+
+// orderStatusNotification tells the buyer their order was accepted, declined or completed
+func orderStatusNotification(order *models.Order) *models.Notification {
+	notificationType := models.NotificationOrderAccepted
+	switch order.Status {
+	case models.DECLINED:
+		notificationType = models.NotificationOrderDeclined
+	case models.COMPLETED:
+		notificationType = models.NotificationOrderCompleted
+	}
+	status := strings.ToLower(string(order.Status))
+	return &models.Notification{
+		Role:        models.RoleUser,
+		RecipientID: order.UserID,
+		Type:        notificationType,
+		Title:       "Order " + status,
+		Message:     fmt.Sprintf("Your order #%d was %s", order.ID, status),
+		OrderID:     &order.ID,
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const PasswordResetTokenValidity = time.Hour
+
+// Forgot User password
+func (u *HTTPHandler) ForgotUserPassword(c *gin.Context) {
+	u.forgotPassword(c, models.RoleUser)
+}
+
+// Forgot Seller password
+func (u *HTTPHandler) ForgotSellerPassword(c *gin.Context) {
+	u.forgotPassword(c, models.RoleSeller)
+}
+
+// Reset User password
+func (u *HTTPHandler) ResetUserPassword(c *gin.Context) {
+	u.resetPassword(c, models.RoleUser)
+}
+
+// Reset Seller password
+func (u *HTTPHandler) ResetSellerPassword(c *gin.Context) {
+	u.resetPassword(c, models.RoleSeller)
+}
+
+// forgotPassword mails a reset link. It answers the same way whether or not the
+// email exists so it cannot be used to discover accounts.
+func (u *HTTPHandler) forgotPassword(c *gin.Context, role models.Role) {
+	var request *models.ForgotPasswordRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	const message = "If the email is registered, a password reset link has been sent"
+
+	account, err := u.accountByEmail(role, strings.TrimSpace(request.Email))
+	if err != nil {
+		util.Response(c, message, 200, nil, nil)
+		return
+	}
+
+	token, err := util.GenerateRandomToken()
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	err = u.Repository.CreateOneTimeToken(&models.OneTimeToken{
+		Purpose:   models.PurposePasswordReset,
+		Role:      role,
+		AccountID: account.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(PasswordResetTokenValidity),
+	})
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	link := appURL(fmt.Sprintf("/%s/password/reset?token=%s", role, url.QueryEscape(token)))
+	err = u.Mailer.Send(&models.MailMessage{
+		To:      account.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask for this, you can ignore this email.\n",
+			account.FirstName, PasswordResetTokenValidity, link),
+	})
+	if err != nil {
+		log.Printf("send password reset email errors: %v\n", err)
+	}
+
+	util.Response(c, message, 200, nil, nil)
+}
+
+// resetPassword redeems a reset token, sets the new password and signs the
+// account out of every existing session
+func (u *HTTPHandler) resetPassword(c *gin.Context, role models.Role) {
+	var request *models.ResetPasswordRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	request.Password = strings.TrimSpace(request.Password)
+	if request.Password == "" {
+		util.Response(c, "Password must not be empty", 400, nil, nil)
+		return
+	}
+	if problems := u.PasswordPolicy.Validate(request.Password); problems != nil {
+		util.Response(c, "Password does not meet the password policy", 400, nil, problems)
+		return
+	}
+
+	// Check for reuse before redeeming the token, so a rejected password does not burn the link
+	tokenHash := util.HashToken(request.Token)
+	token, err := u.Repository.FindOneTimeToken(models.PurposePasswordReset, tokenHash)
+	if err != nil || token.Role != role {
+		util.Response(c, "Invalid or expired reset token", 400, nil, nil)
+		return
+	}
+
+	account, err := u.accountByID(role, token.AccountID)
+	if err != nil {
+		util.Response(c, "Invalid or expired reset token", 400, nil, nil)
+		return
+	}
+	reused, err := u.passwordReused(account.ID, account.PasswordHash, request.Password)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	if reused {
+		util.Response(c, "Password does not meet the password policy", 400, nil, []string{
+			fmt.Sprintf("password must differ from your last %d passwords", u.PasswordPolicy.HistoryDepth),
+		})
+		return
+	}
+
+	if _, err := u.Repository.ConsumeOneTimeToken(models.PurposePasswordReset, tokenHash); err != nil {
+		util.Response(c, "Invalid or expired reset token", 400, nil, nil)
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(request.Password)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	if err := u.setAccountPassword(token.AccountID, hashedPassword); err != nil {
+		util.Response(c, "Error updating password", 500, err.Error(), nil)
+		return
+	}
+	u.recordPassword(token.AccountID, hashedPassword)
+
+	if err := u.Repository.RevokeAllSessions(token.AccountID); err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	// API keys outlive sessions, so keys created by whoever knew the old password go too
+	if err := u.Repository.RevokeAccountAPIKeys(token.AccountID); err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Password reset successfully", 200, nil, nil)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bufio"
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordPolicy decides which new passwords are acceptable
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength is capped by bcrypt, which only looks at the first 72 bytes
+	MaxLength int
+	// HistoryDepth is how many previous passwords an account may not reuse
+	HistoryDepth int
+	// breached holds known compromised passwords loaded from PASSWORD_BREACHED_LIST
+	breached map[string]struct{}
+}
+
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:    8,
+		MaxLength:    72,
+		HistoryDepth: 5,
+	}
+}
+
+// LoadPasswordPolicy reads PASSWORD_MIN_LENGTH and PASSWORD_HISTORY, and the breached
+// password list from the file named by PASSWORD_BREACHED_LIST (one password per line)
+func LoadPasswordPolicy() (PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy()
+
+	if value := os.Getenv("PASSWORD_MIN_LENGTH"); value != "" {
+		minLength, err := strconv.Atoi(value)
+		if err != nil || minLength < 1 || minLength > policy.MaxLength {
+			return policy, fmt.Errorf("invalid PASSWORD_MIN_LENGTH %q", value)
+		}
+		policy.MinLength = minLength
+	}
+
+	if value := os.Getenv("PASSWORD_HISTORY"); value != "" {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 0 {
+			return policy, fmt.Errorf("invalid PASSWORD_HISTORY %q", value)
+		}
+		policy.HistoryDepth = depth
+	}
+
+	if path := os.Getenv("PASSWORD_BREACHED_LIST"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return policy, err
+		}
+		defer file.Close()
+
+		policy.breached = make(map[string]struct{})
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if password := strings.TrimSpace(scanner.Text()); password != "" {
+				policy.breached[password] = struct{}{}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return policy, err
+		}
+	}
+
+	return policy, nil
+}
+
+// Validate returns the reasons password is not acceptable, or nil if it is
+func (p PasswordPolicy) Validate(password string) []string {
+	var problems []string
+	if len(password) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("password must be at least %d characters", p.MinLength))
+	}
+	if len(password) > p.MaxLength {
+		problems = append(problems, fmt.Sprintf("password must be at most %d bytes", p.MaxLength))
+	}
+	if _, found := p.breached[password]; found {
+		problems = append(problems, "password has appeared in a data breach, choose another one")
+	}
+	return problems
+}
+
+// passwordReused reports whether password matches the account's current hash or one
+// of its last HistoryDepth passwords
+func (u *HTTPHandler) passwordReused(accountID uint, currentHash string, password string) (bool, error) {
+	if u.PasswordPolicy.HistoryDepth == 0 {
+		return false, nil
+	}
+	if bcrypt.CompareHashAndPassword([]byte(currentHash), []byte(password)) == nil {
+		return true, nil
+	}
+
+	history, err := u.Repository.ListPasswordHistory(accountID, u.PasswordPolicy.HistoryDepth)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range history {
+		if bcrypt.CompareHashAndPassword([]byte(entry.PasswordHash), []byte(password)) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordPassword adds a newly set password hash to the account's history
+func (u *HTTPHandler) recordPassword(accountID uint, hashedPassword string) {
+	if u.PasswordPolicy.HistoryDepth == 0 {
+		return
+	}
+	err := u.Repository.AddPasswordHistory(&models.PasswordHistory{
+		AccountID:    accountID,
+		PasswordHash: hashedPassword,
+	}, u.PasswordPolicy.HistoryDepth)
+	if err != nil {
+		log.Printf("record password history errors: %v\n", err)
+	}
+}
+
+// rehashPassword upgrades a hash made with an outdated bcrypt cost, using the password
+// that has just been verified against it. Failures are logged; the login goes ahead.
+func rehashPassword(hashedPassword *string, password string, save func() error) {
+	if !util.NeedsRehash(*hashedPassword) {
+		return
+	}
+
+	newHash, err := util.HashPassword(password)
+	if err != nil {
+		log.Printf("rehash password errors: %v\n", err)
+		return
+	}
+	*hashedPassword = newHash
+	if err := save(); err != nil {
+		log.Printf("save rehashed password errors: %v\n", err)
+	}
+}
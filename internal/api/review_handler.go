@@ -0,0 +1,177 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// review a product the buyer has received
+func (u *HTTPHandler) CreateReview(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	productID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid product ID", 400, err.Error(), nil)
+		return
+	}
+
+	var request *models.CreateReviewRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	product, err := u.Repository.GetProductByID(productID)
+	if err != nil {
+		util.Response(c, "Product not found", 404, err.Error(), nil)
+		return
+	}
+
+	// Sellers cannot rate their own products through their buyer membership
+	seller, err := u.Repository.GetSellerByAccountID(user.AccountID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	if seller != nil && seller.ID == product.SellerID {
+		util.Response(c, "You cannot review your own product", 403, nil, nil)
+		return
+	}
+
+	order, err := u.Repository.GetCompletedOrderForProduct(user.ID, productID)
+	if err != nil {
+		util.Response(c, "Only buyers with a completed order for this product can review it", 403, nil, nil)
+		return
+	}
+
+	if _, err := u.Repository.GetReviewByUserAndProduct(user.ID, productID); err == nil {
+		util.Response(c, "You have already reviewed this product", 400, nil, nil)
+		return
+	}
+
+	review := &models.Review{
+		ProductID: productID,
+		UserID:    user.ID,
+		User:      user,
+		OrderID:   order.ID,
+		Rating:    request.Rating,
+		Body:      strings.TrimSpace(request.Body),
+	}
+	if err := u.Repository.CreateReview(review); err != nil {
+		// A concurrent request may have saved a review since the check above
+		if errors.Is(err, models.ErrAlreadyReviewed) {
+			util.Response(c, "You have already reviewed this product", 400, nil, nil)
+			return
+		}
+		util.Response(c, "Review not created", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Review created", 200, models.NewReviewResponse(review), nil)
+}
+
+// list a product's reviews, sorted by ?sort=recent (default), rating_desc or rating_asc
+func (u *HTTPHandler) ListProductReviews(c *gin.Context) {
+	productID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid product ID", 400, err.Error(), nil)
+		return
+	}
+
+	sort, ok := models.ParseReviewSort(c.Query("sort"))
+	if !ok {
+		util.Response(c, "Invalid sort, use recent, rating_desc or rating_asc", 400, nil, nil)
+		return
+	}
+
+	product, err := u.Repository.GetProductByID(productID)
+	if err != nil {
+		util.Response(c, "Product not found", 404, err.Error(), nil)
+		return
+	}
+
+	reviews, err := u.Repository.ListProductReviews(productID, sort)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Reviews fetched", 200, gin.H{
+		"rating_average": product.RatingAverage,
+		"rating_count":   product.RatingCount,
+		"reviews":        models.NewReviewResponses(reviews),
+	}, nil)
+}
+
+// list the reviews of the seller's products, sorted like ListProductReviews
+func (u *HTTPHandler) ListSellerReviews(c *gin.Context) {
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Invalid token", 401, err.Error(), nil)
+		return
+	}
+
+	sort, ok := models.ParseReviewSort(c.Query("sort"))
+	if !ok {
+		util.Response(c, "Invalid sort, use recent, rating_desc or rating_asc", 400, nil, nil)
+		return
+	}
+
+	reviews, err := u.Repository.ListSellerReviews(seller.ID, sort)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Reviews fetched", 200, gin.H{
+		"reviews": models.NewReviewResponses(reviews),
+	}, nil)
+}
+
+// answer a review of one of the seller's products. Each review can be answered once.
+func (u *HTTPHandler) RespondToReview(c *gin.Context) {
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Invalid token", 401, err.Error(), nil)
+		return
+	}
+
+	reviewID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid review ID", 400, err.Error(), nil)
+		return
+	}
+
+	var request *models.RespondToReviewRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	review, err := u.Repository.GetReviewByID(reviewID)
+	if err != nil || review.Product == nil || review.Product.SellerID != seller.ID {
+		util.Response(c, "Review not found", 404, nil, nil)
+		return
+	}
+
+	err = u.Repository.RespondToReview(review, strings.TrimSpace(request.Response))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		util.Response(c, "Review already has a response", 400, nil, nil)
+		return
+	}
+	if err != nil {
+		util.Response(c, "Error saving response", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Response saved", 200, models.NewReviewResponse(review), nil)
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxSearchLength = 200
+
+// search the catalogue with ?q=, ranked by relevance. The catalogue filters, sort and
+// page parameters apply to the results as well.
+func (u *HTTPHandler) SearchProducts(c *gin.Context) {
+	terms := strings.TrimSpace(c.Query("q"))
+	if terms == "" {
+		util.Response(c, "Search terms must not be empty", 400, nil, nil)
+		return
+	}
+	if len(terms) > maxSearchLength {
+		util.Response(c, "Search terms are too long", 400, nil, nil)
+		return
+	}
+
+	// Search results are ranked unless the client asks for another order
+	query, ok := bindProductQuery(c, models.ProductSortRelevance)
+	if !ok {
+		return
+	}
+
+	results, total, err := u.Repository.SearchProducts(terms, query)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Products found", 200, gin.H{
+		"results": models.NewProductSearchResponses(results),
+		"meta":    models.NewPageMeta(total, query.Page, query.Limit),
+	}, nil)
+}
@@ -1,45 +1,53 @@
 package api
 
 import (
-	"e-commerce/internal/middleware"
 	"e-commerce/internal/models"
 	"e-commerce/internal/util"
-	"os"
+	"errors"
+	"log"
 	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-// Create Seller
+// Create Seller. Existing accounts add the role from a signed-in session instead.
 func (u *HTTPHandler) CreateSeller(c *gin.Context) {
-	var seller *models.Seller
-	if err := c.ShouldBind(&seller); err != nil {
+	var request *models.CreateSellerRequest
+	if err := c.ShouldBind(&request); err != nil {
 		util.Response(c, "invalid request", 400, err.Error(), nil)
 		return
 	}
 
-	_, err := u.Repository.FindSellerByEmail(seller.Email)
-	if err == nil {
-		util.Response(c, "User already exists", 400, "Bad request body", nil)
+	identity, ok := u.signupAccount(c, models.RoleSeller, &request.SignupRequest)
+	if !ok {
 		return
 	}
-
-	// Hash the password
-	hashedPassword, err := util.HashPassword(seller.Password)
-	if err != nil {
-		util.Response(c, "Internal server error", 500, err.Error(), nil)
+	if identity == nil {
+		util.Response(c, "Seller created, check your email to verify your account", 200, nil, nil)
 		return
 	}
-	seller.Password = hashedPassword
 
-	err = u.Repository.CreateSeller(seller)
-	if err != nil {
+	seller := &models.Seller{
+		AccountID:     identity.ID,
+		Account:       identity,
+		StoreName:     request.StoreName,
+		StoreCategory: request.StoreCategory,
+	}
+	if err := u.Repository.CreateSeller(seller); err != nil {
 		util.Response(c, "Seller not created", 500, err.Error(), nil)
 		return
 	}
-	util.Response(c, "Seller created", 200, nil, nil)
+
+	u.recordPassword(seller.AccountID, identity.Password)
+
+	// The account exists even if the email fails; the seller can ask for a resend
+	if err := u.sendVerificationEmail(models.RoleSeller, u.sellerAccount(seller)); err != nil {
+		log.Printf("send verification email errors: %v\n", err)
+	}
+
+	util.Response(c, "Seller created, check your email to verify your account", 200, nil, nil)
 
 }
 
@@ -64,31 +72,36 @@ func (u *HTTPHandler) LoginSeller(c *gin.Context) {
 		return
 	}
 
+	if !u.loginAllowed(c, loginRequest.Email) {
+		return
+	}
+
 	Seller, err := u.Repository.FindSellerByEmail(loginRequest.Email)
 	if err != nil {
-		util.Response(c, "Email does not exist", 404, err.Error(), nil)
+		compareDummyPassword(loginRequest.Password)
+		u.loginFailed(c, models.RoleSeller, loginRequest.Email)
+		util.Response(c, invalidCredentials, 401, nil, nil)
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(Seller.Password), []byte(loginRequest.Password))
+	err = bcrypt.CompareHashAndPassword([]byte(Seller.Account.Password), []byte(loginRequest.Password))
 	if err != nil {
-		util.Response(c, "Invalid password", 400, err.Error(), nil)
+		u.loginFailed(c, models.RoleSeller, loginRequest.Email)
+		util.Response(c, invalidCredentials, 401, nil, nil)
 		return
 	}
+	rehashPassword(&Seller.Account.Password, loginRequest.Password, u.sellerAccount(Seller).Save)
 
-	accessClaims, refreshClaims := middleware.GenerateClaims(Seller.Email)
-
-	secret := os.Getenv("JWT_SECRET")
-
-	accessToken, err := middleware.GenerateToken(jwt.SigningMethodHS256, accessClaims, &secret)
-	if err != nil {
-		util.Response(c, "Error generating access token", 500, err.Error(), nil)
+	// With two-factor enabled the password only earns a challenge token
+	if Seller.Account.TOTPEnabled {
+		u.requireSecondFactor(c, models.RoleSeller, Seller.AccountID)
 		return
 	}
+	u.loginSucceeded(loginRequest.Email)
 
-	refreshToken, err := middleware.GenerateToken(jwt.SigningMethodHS256, refreshClaims, &secret)
+	accessToken, refreshToken, err := u.startSession(c, u.sellerAccount(Seller))
 	if err != nil {
-		util.Response(c, "Error generating refresh token", 500, err.Error(), nil)
+		util.Response(c, "Error generating tokens", 500, err.Error(), nil)
 		return
 	}
 
@@ -96,7 +109,7 @@ func (u *HTTPHandler) LoginSeller(c *gin.Context) {
 	c.Header("refresh_token", *refreshToken)
 
 	util.Response(c, "Login successful", 200, gin.H{
-		"Seller":        Seller,
+		"Seller":        models.NewSellerResponse(Seller),
 		"access_token":  accessToken,
 		"refresh_token": refreshToken,
 	}, nil)
@@ -110,14 +123,17 @@ func (u *HTTPHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	var product *models.Product
-	if err := c.ShouldBind(&product); err != nil {
+	var request *models.CreateProductRequest
+	if err := c.ShouldBind(&request); err != nil {
 		util.Response(c, "invalid request", 400, err.Error(), nil)
 		return
 	}
 
-	product.SellerID = seller.ID
+	if !u.categoryExists(c, request.CategoryID) {
+		return
+	}
 
+	product := request.NewProduct(seller.ID)
 	err = u.Repository.CreateProduct(product)
 	if err != nil {
 		util.Response(c, "Product not created", 500, err.Error(), nil)
@@ -135,11 +151,22 @@ func (u *HTTPHandler) ListOrders(c *gin.Context) {
 		return
 	}
 
+	uniqueOrders, err := u.sellerOrders(seller.ID)
+	if err != nil {
+		util.Response(c, "Error fetching orders", 500, err.Error(), nil)
+		return
+	}
+
+	// Send the response
+	util.Response(c, "Orders fetched successfully", 200, models.NewOrderResponses(uniqueOrders), nil)
+}
+
+// sellerOrders collects the orders that contain any of the seller's products
+func (u *HTTPHandler) sellerOrders(sellerID uint) ([]*models.Order, error) {
 	// Fetch all products belonging to the seller
 	var products []models.Product
-	if err := u.Repository.GetProductsBySellerID(seller.ID, &products); err != nil {
-		util.Response(c, "Error fetching seller's products", 500, err.Error(), nil)
-		return
+	if err := u.Repository.GetProductsBySellerID(sellerID, &products); err != nil {
+		return nil, err
 	}
 
 	// Collect all order IDs associated with the seller's products
@@ -147,22 +174,39 @@ func (u *HTTPHandler) ListOrders(c *gin.Context) {
 	for _, product := range products {
 		var productOrders []models.Order
 		if err := u.Repository.GetOrdersByProductID(product.ID, &productOrders); err != nil {
-			util.Response(c, "Error fetching orders for product", 500, err.Error(), nil)
-			return
+			return nil, err
 		}
 		orders = append(orders, productOrders...)
 	}
 
 	// Remove duplicate orders (optional, depending on the structure of your database queries)
 	uniqueOrders := util.RemoveDuplicateOrders(orders)
+	result := make([]*models.Order, len(uniqueOrders))
+	for i := range uniqueOrders {
+		result[i] = &uniqueOrders[i]
+	}
+	return result, nil
+}
 
-	// Send the response
-	util.Response(c, "Orders fetched successfully", 200, uniqueOrders, nil)
+// sellerOwnsOrder responds with 403 unless every item of the order is one of the
+// seller's products. Checkout gives each seller an order of their own, so an order-level
+// change never touches another seller's items.
+func (u *HTTPHandler) sellerOwnsOrder(c *gin.Context, sellerID, orderID uint) bool {
+	owns, err := u.Repository.OrderBelongsToSeller(orderID, sellerID)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return false
+	}
+	if !owns {
+		util.Response(c, "Order does not belong to the seller", 403, nil, nil)
+		return false
+	}
+	return true
 }
 
 // Accept the order
 func (u *HTTPHandler) AcceptOrder(c *gin.Context) {
-	_, err := u.GetSellerFromContext(c)
+	seller, err := u.GetSellerFromContext(c)
 	if err != nil {
 		util.Response(c, "Invalid token", 401, err.Error(), nil)
 		return
@@ -187,10 +231,13 @@ func (u *HTTPHandler) AcceptOrder(c *gin.Context) {
 		util.Response(c, "Order not found", 404, err.Error(), nil)
 		return
 	}
+	if !u.sellerOwnsOrder(c, seller.ID, order.ID) {
+		return
+	}
 
-	//check if order is already accepted
-	if order.Status == "ACCEPTED" {
-		util.Response(c, "Order already accepted", 400, nil, nil)
+	// Only a placed order can be accepted, so a completed one cannot be reopened
+	if order.Status != "PLACED" {
+		util.Response(c, "Only placed orders can be accepted", 400, nil, nil)
 		return
 	}
 
@@ -200,13 +247,14 @@ func (u *HTTPHandler) AcceptOrder(c *gin.Context) {
 		util.Response(c, "Error updating order", 500, err.Error(), nil)
 		return
 	}
+	u.notify(orderStatusNotification(order))
 
 	util.Response(c, "Order accepted", 200, nil, nil)
 }
 
 // Decline the order
 func (u *HTTPHandler) DeclineOrder(c *gin.Context) {
-	_, err := u.GetSellerFromContext(c)
+	seller, err := u.GetSellerFromContext(c)
 	if err != nil {
 		util.Response(c, "Invalid token", 401, err.Error(), nil)
 		return
@@ -231,54 +279,156 @@ func (u *HTTPHandler) DeclineOrder(c *gin.Context) {
 		util.Response(c, "Order not found", 404, err.Error(), nil)
 		return
 	}
+	if !u.sellerOwnsOrder(c, seller.ID, order.ID) {
+		return
+	}
 
-	//check if order is already declined
-	if order.Status == "DECLINED" {
-		util.Response(c, "Order already declined", 400, nil, nil)
+	// Only a placed order can be declined; later ones may already have shipped
+	if order.Status != "PLACED" {
+		util.Response(c, "Only placed orders can be declined", 400, nil, nil)
 		return
 	}
 
-	// Update the order status to declined
-	order.Status = "DECLINED"
-	if err := u.Repository.UpdateOrder(order); err != nil {
+	// Update the order status to declined and put its units back in stock
+	if err := u.Repository.DeclineOrder(order, seller.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			util.Response(c, "Only placed orders can be declined", 400, nil, nil)
+			return
+		}
 		util.Response(c, "Error updating order", 500, err.Error(), nil)
 		return
 	}
+	u.notify(orderStatusNotification(order))
 
 	util.Response(c, "Order declined", 200, nil, nil)
 }
 
-// delete product
-func (u *HTTPHandler) DeleteProduct(c *gin.Context) {
+// Complete the order once it has been delivered. Buyers can then review its products.
+func (u *HTTPHandler) CompleteOrder(c *gin.Context) {
 	seller, err := u.GetSellerFromContext(c)
 	if err != nil {
 		util.Response(c, "Invalid token", 401, err.Error(), nil)
 		return
 	}
 
-	productID := c.Param("id")
-	if productID == "" {
-		util.Response(c, "Product ID not provided", 400, nil, nil)
+	orderID := c.Param("id")
+	if orderID == "" {
+		util.Response(c, "Order ID not provided", 400, nil, nil)
 		return
 	}
 
 	//convert id to uint
-	productIDUint, err := util.ConvertStringToUint(productID)
+	orderIDUint, err := util.ConvertStringToUint(orderID)
 	if err != nil {
-		util.Response(c, "Invalid product ID", 400, err.Error(), nil)
+		util.Response(c, "Invalid order ID", 400, err.Error(), nil)
 		return
 	}
 
-	// Get the product from the database
-	product, err := u.Repository.GetProductByID(productIDUint)
+	// Get the order from the database
+	order, err := u.Repository.GetOrderByID(orderIDUint)
 	if err != nil {
-		util.Response(c, "Product not found", 404, err.Error(), nil)
+		util.Response(c, "Order not found", 404, err.Error(), nil)
+		return
+	}
+	if !u.sellerOwnsOrder(c, seller.ID, order.ID) {
 		return
 	}
 
-	// Check if the product belongs to the seller
-	if product.SellerID != seller.ID {
-		util.Response(c, "Product does not belong to seller", 400, nil, nil)
+	// Only an accepted order can be completed
+	if order.Status != "ACCEPTED" {
+		util.Response(c, "Only accepted orders can be completed", 400, nil, nil)
+		return
+	}
+
+	// Update the order status to completed
+	order.Status = "COMPLETED"
+	if err := u.Repository.UpdateOrder(order); err != nil {
+		util.Response(c, "Error updating order", 500, err.Error(), nil)
+		return
+	}
+	u.notify(orderStatusNotification(order))
+
+	util.Response(c, "Order completed", 200, nil, nil)
+}
+
+// list the seller's own products, including those off sale
+func (u *HTTPHandler) ListSellerProducts(c *gin.Context) {
+	seller, err := u.GetSellerFromContext(c)
+	if err != nil {
+		util.Response(c, "Invalid token", 401, err.Error(), nil)
+		return
+	}
+
+	var products []models.Product
+	if err := u.Repository.GetProductsBySellerID(seller.ID, &products); err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Products fetched", 200, gin.H{
+		"products": models.NewProductResponses(products),
+	}, nil)
+}
+
+// update a product. Only the fields present in the body change.
+func (u *HTTPHandler) UpdateProduct(c *gin.Context) {
+	var request *models.UpdateProductRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	product, ok := u.sellerProduct(c)
+	if !ok {
+		return
+	}
+	if request.CategoryID != nil && !u.categoryExists(c, *request.CategoryID) {
+		return
+	}
+
+	before := *product
+	request.Apply(product)
+	if err := u.Repository.UpdateProduct(product); err != nil {
+		util.Response(c, "Error updating product", 500, err.Error(), nil)
+		return
+	}
+	if notification := u.lowStockNotification(&before, product.Quantity); notification != nil {
+		u.notify(notification)
+	}
+
+	util.Response(c, "Product updated", 200, models.NewProductResponse(product), nil)
+}
+
+// put a product on or off sale
+func (u *HTTPHandler) SetProductStatus(c *gin.Context) {
+	var request *models.ProductStatusRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	product, ok := u.sellerProduct(c)
+	if !ok {
+		return
+	}
+
+	product.Status = *request.Status
+	if err := u.Repository.UpdateProduct(product); err != nil {
+		util.Response(c, "Error updating product", 500, err.Error(), nil)
+		return
+	}
+
+	message := "Product deactivated"
+	if product.Status {
+		message = "Product activated"
+	}
+	util.Response(c, message, 200, models.NewProductResponse(product), nil)
+}
+
+// delete product
+func (u *HTTPHandler) DeleteProduct(c *gin.Context) {
+	product, ok := u.sellerProduct(c)
+	if !ok {
 		return
 	}
 
@@ -291,12 +441,38 @@ func (u *HTTPHandler) DeleteProduct(c *gin.Context) {
 	util.Response(c, "Product deleted", 200, nil, nil)
 }
 
-// clear all products, orders
-func (u *HTTPHandler) ClearAll(c *gin.Context) {
-	err := u.Repository.ClearAll()
+// sellerProduct loads the product named in the path and checks it belongs to the seller
+func (u *HTTPHandler) sellerProduct(c *gin.Context) (*models.Product, bool) {
+	seller, err := u.GetSellerFromContext(c)
 	if err != nil {
-		util.Response(c, "Error clearing all data", 500, err.Error(), nil)
-		return
+		util.Response(c, "Invalid token", 401, err.Error(), nil)
+		return nil, false
+	}
+
+	productID := c.Param("id")
+	if productID == "" {
+		util.Response(c, "Product ID not provided", 400, nil, nil)
+		return nil, false
+	}
+
+	//convert id to uint
+	productIDUint, err := util.ConvertStringToUint(productID)
+	if err != nil {
+		util.Response(c, "Invalid product ID", 400, err.Error(), nil)
+		return nil, false
+	}
+
+	// Get the product from the database
+	product, err := u.Repository.GetProductByID(productIDUint)
+	if err != nil {
+		util.Response(c, "Product not found", 404, err.Error(), nil)
+		return nil, false
+	}
+
+	// Check if the product belongs to the seller
+	if product.SellerID != seller.ID {
+		util.Response(c, "Product does not belong to seller", 400, nil, nil)
+		return nil, false
 	}
-	util.Response(c, "All data cleared", 200, nil, nil)
+	return product, true
 }
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"e-commerce/internal/middleware"
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// List User sessions
+func (u *HTTPHandler) ListUserSessions(c *gin.Context) {
+	u.listSessions(c, models.RoleUser)
+}
+
+// List Seller sessions
+func (u *HTTPHandler) ListSellerSessions(c *gin.Context) {
+	u.listSessions(c, models.RoleSeller)
+}
+
+// List Admin sessions
+func (u *HTTPHandler) ListAdminSessions(c *gin.Context) {
+	u.listSessions(c, models.RoleAdmin)
+}
+
+// Revoke one User session
+func (u *HTTPHandler) RevokeUserSession(c *gin.Context) {
+	u.revokeSession(c, models.RoleUser)
+}
+
+// Revoke one Seller session
+func (u *HTTPHandler) RevokeSellerSession(c *gin.Context) {
+	u.revokeSession(c, models.RoleSeller)
+}
+
+// Revoke one Admin session
+func (u *HTTPHandler) RevokeAdminSession(c *gin.Context) {
+	u.revokeSession(c, models.RoleAdmin)
+}
+
+// Revoke every User session
+func (u *HTTPHandler) RevokeAllUserSessions(c *gin.Context) {
+	u.revokeAllSessions(c, models.RoleUser)
+}
+
+// Revoke every Seller session
+func (u *HTTPHandler) RevokeAllSellerSessions(c *gin.Context) {
+	u.revokeAllSessions(c, models.RoleSeller)
+}
+
+// Revoke every Admin session
+func (u *HTTPHandler) RevokeAllAdminSessions(c *gin.Context) {
+	u.revokeAllSessions(c, models.RoleAdmin)
+}
+
+// currentSessionID returns the sid of the access token used for this request
+func (u *HTTPHandler) currentSessionID(c *gin.Context) string {
+	claims, err := u.GetClaimsFromContext(c)
+	if err != nil {
+		return ""
+	}
+	return middleware.GetSessionID(claims)
+}
+
+// listSessions returns the active sessions of the caller's account in every role,
+// flagging the one making the request
+func (u *HTTPHandler) listSessions(c *gin.Context, role models.Role) {
+	account, err := u.accountFromContext(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	sessions, err := u.Repository.ListSessions(account.ID)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Sessions fetched", 200, gin.H{
+		"sessions": models.NewSessionResponses(sessions, u.currentSessionID(c)),
+	}, nil)
+}
+
+// revokeSession signs one of the caller's devices out
+func (u *HTTPHandler) revokeSession(c *gin.Context, role models.Role) {
+	account, err := u.accountFromContext(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	sessionID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid session ID", 400, err.Error(), nil)
+		return
+	}
+
+	session, err := u.Repository.GetSessionByID(sessionID)
+	if err != nil || session.AccountID != account.ID {
+		util.Response(c, "Session not found", 404, nil, nil)
+		return
+	}
+
+	if err := u.Repository.RevokeSession(session); err != nil {
+		util.Response(c, "Error revoking session", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Session revoked", 200, nil, nil)
+}
+
+// revokeAllSessions signs the caller out on every device and in every role, including this one
+func (u *HTTPHandler) revokeAllSessions(c *gin.Context, role models.Role) {
+	account, err := u.accountFromContext(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	if err := u.Repository.RevokeAllSessions(account.ID); err != nil {
+		util.Response(c, "Error revoking sessions", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Signed out of all sessions", 200, nil, nil)
+}
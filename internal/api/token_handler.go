@@ -0,0 +1,160 @@
+package api
+
+import (
+	"e-commerce/internal/middleware"
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// issueTokens signs a new access/refresh pair for a session and records the refresh token
+func (u *HTTPHandler) issueTokens(account *account, sessionID string) (*string, *string, error) {
+	accessClaims, refreshClaims := middleware.GenerateClaims(middleware.Subject{
+		Role:    account.Role,
+		ID:      account.ID,
+		Email:   account.Email,
+		Version: account.TokenVersion,
+	}, sessionID)
+
+	accessToken, err := middleware.GenerateToken(u.Keys, accessClaims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := middleware.GenerateToken(u.Keys, refreshClaims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expiresAt := time.Unix(refreshClaims["exp"].(int64), 0)
+	err = u.Repository.CreateRefreshToken(&models.RefreshToken{
+		TokenID:   refreshClaims["jti"].(string),
+		FamilyID:  sessionID,
+		Role:      account.Role,
+		AccountID: account.ID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := u.Repository.ExtendSession(sessionID, expiresAt); err != nil {
+		return nil, nil, err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// startSession records a new login for the device making the request and issues its first tokens
+func (u *HTTPHandler) startSession(c *gin.Context, account *account) (*string, *string, error) {
+	session := &models.Session{
+		Role:       account.Role,
+		AccountID:  account.ID,
+		FamilyID:   middleware.NewTokenID(),
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(middleware.RefreshTokenValidity),
+	}
+	if err := u.Repository.CreateSession(session); err != nil {
+		return nil, nil, err
+	}
+	return u.issueTokens(account, session.FamilyID)
+}
+
+// Refresh User token
+func (u *HTTPHandler) RefreshUserToken(c *gin.Context) {
+	u.refreshToken(c, models.RoleUser)
+}
+
+// Refresh Seller token
+func (u *HTTPHandler) RefreshSellerToken(c *gin.Context) {
+	u.refreshToken(c, models.RoleSeller)
+}
+
+// Refresh Admin token
+func (u *HTTPHandler) RefreshAdminToken(c *gin.Context) {
+	u.refreshToken(c, models.RoleAdmin)
+}
+
+// refreshToken rotates a refresh token. Presenting a token that was already rotated
+// is treated as theft and revokes every token in its family.
+func (u *HTTPHandler) refreshToken(c *gin.Context, role models.Role) {
+	var request *models.RefreshTokenRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	_, claims, err := middleware.AuthorizeToken(&request.RefreshToken, u.Keys)
+	if err != nil || middleware.ValidateClaims(claims, middleware.RefreshTokenType, role) != nil {
+		util.Response(c, "Invalid refresh token", 401, nil, nil)
+		return
+	}
+
+	subject, _ := middleware.GetSubject(claims)
+	tokenID, _ := claims["jti"].(string)
+	stored, err := u.Repository.FindRefreshToken(tokenID)
+	if err != nil || stored.Role != role || stored.AccountID != subject {
+		util.Response(c, "Invalid refresh token", 401, nil, nil)
+		return
+	}
+
+	if stored.RevokedAt != nil {
+		util.Response(c, "Refresh token revoked", 401, nil, nil)
+		return
+	}
+
+	if stored.UsedAt != nil {
+		u.revokeReusedFamily(c, stored)
+		return
+	}
+
+	if !u.Repository.TouchSession(role, stored.FamilyID) {
+		util.Response(c, "Session revoked", 401, nil, nil)
+		return
+	}
+
+	account, err := u.accountByID(role, stored.AccountID)
+	if err != nil {
+		util.Response(c, "Invalid refresh token", 401, nil, nil)
+		return
+	}
+
+	if err := u.Repository.UseRefreshToken(stored); err != nil {
+		if errors.Is(err, models.ErrRefreshTokenReused) {
+			u.revokeReusedFamily(c, stored)
+			return
+		}
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	accessToken, refreshToken, err := u.issueTokens(account, stored.FamilyID)
+	if err != nil {
+		util.Response(c, "Error generating tokens", 500, err.Error(), nil)
+		return
+	}
+
+	c.Header("access_token", *accessToken)
+	c.Header("refresh_token", *refreshToken)
+
+	util.Response(c, "Token refreshed", 200, gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	}, nil)
+}
+
+// revokeReusedFamily handles a replayed refresh token by revoking its whole family
+func (u *HTTPHandler) revokeReusedFamily(c *gin.Context, token *models.RefreshToken) {
+	log.Printf("refresh token reuse detected for %s %d, revoking family %s\n", token.Role, token.AccountID, token.FamilyID)
+	if err := u.Repository.RevokeRefreshTokenFamily(token.FamilyID); err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "Refresh token reuse detected", 401, nil, nil)
+}
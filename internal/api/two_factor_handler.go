@@ -0,0 +1,345 @@
+package api
+
+import (
+	"crypto/rand"
+	"e-commerce/internal/middleware"
+	"e-commerce/internal/models"
+	"e-commerce/internal/totp"
+	"e-commerce/internal/util"
+	"encoding/base32"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const recoveryCodeCount = 10
+
+// Enroll User in two-factor authentication
+func (u *HTTPHandler) EnrollUserTwoFactor(c *gin.Context) {
+	u.enrollTwoFactor(c, models.RoleUser)
+}
+
+// Enroll Seller in two-factor authentication
+func (u *HTTPHandler) EnrollSellerTwoFactor(c *gin.Context) {
+	u.enrollTwoFactor(c, models.RoleSeller)
+}
+
+// Confirm User two-factor enrollment
+func (u *HTTPHandler) ConfirmUserTwoFactor(c *gin.Context) {
+	u.confirmTwoFactor(c, models.RoleUser)
+}
+
+// Confirm Seller two-factor enrollment
+func (u *HTTPHandler) ConfirmSellerTwoFactor(c *gin.Context) {
+	u.confirmTwoFactor(c, models.RoleSeller)
+}
+
+// Disable User two-factor authentication
+func (u *HTTPHandler) DisableUserTwoFactor(c *gin.Context) {
+	u.disableTwoFactor(c, models.RoleUser)
+}
+
+// Disable Seller two-factor authentication
+func (u *HTTPHandler) DisableSellerTwoFactor(c *gin.Context) {
+	u.disableTwoFactor(c, models.RoleSeller)
+}
+
+// Complete User login with a second factor
+func (u *HTTPHandler) LoginUserTwoFactor(c *gin.Context) {
+	u.loginTwoFactor(c, models.RoleUser)
+}
+
+// Complete Seller login with a second factor
+func (u *HTTPHandler) LoginSellerTwoFactor(c *gin.Context) {
+	u.loginTwoFactor(c, models.RoleSeller)
+}
+
+// Enroll Admin in two-factor authentication
+func (u *HTTPHandler) EnrollAdminTwoFactor(c *gin.Context) {
+	u.enrollTwoFactor(c, models.RoleAdmin)
+}
+
+// Confirm Admin two-factor enrollment
+func (u *HTTPHandler) ConfirmAdminTwoFactor(c *gin.Context) {
+	u.confirmTwoFactor(c, models.RoleAdmin)
+}
+
+// Disable Admin two-factor authentication
+func (u *HTTPHandler) DisableAdminTwoFactor(c *gin.Context) {
+	u.disableTwoFactor(c, models.RoleAdmin)
+}
+
+// Complete Admin login with a second factor
+func (u *HTTPHandler) LoginAdminTwoFactor(c *gin.Context) {
+	u.loginTwoFactor(c, models.RoleAdmin)
+}
+
+// enrollTwoFactor generates a new secret. It is not enforced until confirmTwoFactor
+// proves the authenticator app was set up correctly.
+func (u *HTTPHandler) enrollTwoFactor(c *gin.Context, role models.Role) {
+	account, err := u.accountFromContext(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	if account.TwoFactor.TOTPEnabled {
+		util.Response(c, "Two-factor authentication is already enabled", 400, nil, nil)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	account.TwoFactor.TOTPSecret = secret
+	account.TwoFactor.TOTPLastCounter = 0
+
+	if err := account.Save(); err != nil {
+		util.Response(c, "Error saving two-factor secret", 500, err.Error(), nil)
+		return
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "e-commerce"
+	}
+
+	util.Response(c, "Scan the provisioning URI with your authenticator app, then confirm with a code", 200, gin.H{
+		"secret":           secret,
+		"provisioning_uri": totp.ProvisioningURI(secret, issuer, account.Email),
+	}, nil)
+}
+
+// confirmTwoFactor enables two-factor authentication and hands out recovery codes
+func (u *HTTPHandler) confirmTwoFactor(c *gin.Context, role models.Role) {
+	var request *models.TwoFactorCodeRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	account, err := u.accountFromContext(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	if account.TwoFactor.TOTPEnabled {
+		util.Response(c, "Two-factor authentication is already enabled", 400, nil, nil)
+		return
+	}
+	if account.TwoFactor.TOTPSecret == "" {
+		util.Response(c, "Start two-factor enrollment first", 400, nil, nil)
+		return
+	}
+	if !acceptTOTP(account.TwoFactor, request.Code) {
+		util.Response(c, "Invalid two-factor code", 400, nil, nil)
+		return
+	}
+
+	codes, err := u.newRecoveryCodes(account.ID)
+	if err != nil {
+		util.Response(c, "Error generating recovery codes", 500, err.Error(), nil)
+		return
+	}
+
+	account.TwoFactor.TOTPEnabled = true
+	if err := account.Save(); err != nil {
+		util.Response(c, "Error enabling two-factor authentication", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Two-factor authentication enabled. Store these recovery codes somewhere safe, they will not be shown again", 200, gin.H{
+		"recovery_codes": codes,
+	}, nil)
+}
+
+// disableTwoFactor turns two-factor authentication off. It needs a current code so a
+// stolen access token alone cannot remove the second factor.
+func (u *HTTPHandler) disableTwoFactor(c *gin.Context, role models.Role) {
+	var request *models.TwoFactorCodeRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	account, err := u.accountFromContext(c, role)
+	if err != nil {
+		util.Response(c, "Error getting account from context", 500, err.Error(), nil)
+		return
+	}
+
+	if !account.TwoFactor.TOTPEnabled {
+		util.Response(c, "Two-factor authentication is not enabled", 400, nil, nil)
+		return
+	}
+	if !acceptTOTP(account.TwoFactor, request.Code) {
+		util.Response(c, "Invalid two-factor code", 400, nil, nil)
+		return
+	}
+
+	account.TwoFactor.TOTPEnabled = false
+	account.TwoFactor.TOTPSecret = ""
+	account.TwoFactor.TOTPLastCounter = 0
+	if err := account.Save(); err != nil {
+		util.Response(c, "Error disabling two-factor authentication", 500, err.Error(), nil)
+		return
+	}
+
+	if err := u.Repository.ReplaceRecoveryCodes(account.ID, nil); err != nil {
+		util.Response(c, "Error removing recovery codes", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Two-factor authentication disabled", 200, nil, nil)
+}
+
+// requireSecondFactor answers a correct password with a short-lived challenge token
+func (u *HTTPHandler) requireSecondFactor(c *gin.Context, role models.Role, accountID uint) {
+	challengeToken, err := middleware.GenerateToken(u.Keys, middleware.GenerateChallengeClaims(role, accountID))
+	if err != nil {
+		util.Response(c, "Error generating challenge token", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Two-factor authentication required", 200, gin.H{
+		"mfa_required":    true,
+		"challenge_token": challengeToken,
+	}, nil)
+}
+
+// loginTwoFactor exchanges a challenge token and a TOTP or recovery code for tokens.
+// Wrong codes count as failed logins, so the code cannot be brute-forced.
+func (u *HTTPHandler) loginTwoFactor(c *gin.Context, role models.Role) {
+	var request *models.TwoFactorLoginRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	_, claims, err := middleware.AuthorizeToken(&request.ChallengeToken, u.Keys)
+	if err != nil || middleware.ValidateClaims(claims, middleware.ChallengeTokenType, role) != nil {
+		util.Response(c, "Invalid challenge token", 401, nil, nil)
+		return
+	}
+
+	challengeID, _ := claims["jti"].(string)
+	if u.Repository.TokenInBlacklist(challengeID) {
+		util.Response(c, "Invalid challenge token", 401, nil, nil)
+		return
+	}
+
+	accountID, _ := middleware.GetSubject(claims)
+	account, err := u.accountByID(role, accountID)
+	if err != nil || !account.TwoFactor.TOTPEnabled {
+		util.Response(c, "Invalid challenge token", 401, nil, nil)
+		return
+	}
+
+	if !u.loginAllowed(c, account.Email) {
+		return
+	}
+
+	switch {
+	case request.Code != "":
+		if !acceptTOTP(account.TwoFactor, request.Code) {
+			u.loginFailed(c, role, account.Email)
+			util.Response(c, "Invalid two-factor code", 401, nil, nil)
+			return
+		}
+		if err := account.Save(); err != nil {
+			util.Response(c, "Internal server error", 500, err.Error(), nil)
+			return
+		}
+	case request.RecoveryCode != "":
+		err := u.Repository.UseRecoveryCode(account.ID, util.HashToken(normalizeRecoveryCode(request.RecoveryCode)))
+		if err != nil {
+			u.loginFailed(c, role, account.Email)
+			util.Response(c, "Invalid recovery code", 401, nil, nil)
+			return
+		}
+	default:
+		util.Response(c, "Provide a two-factor code or a recovery code", 400, nil, nil)
+		return
+	}
+	u.loginSucceeded(account.Email)
+
+	// A challenge token may only complete one login
+	err = u.Repository.BlacklistToken(&models.BlacklistTokens{
+		TokenID:   challengeID,
+		ExpiresAt: middleware.GetExpiry(claims),
+	})
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	accessToken, refreshToken, err := u.startSession(c, account)
+	if err != nil {
+		util.Response(c, "Error generating tokens", 500, err.Error(), nil)
+		return
+	}
+
+	c.Header("access_token", *accessToken)
+	c.Header("refresh_token", *refreshToken)
+
+	accountKey := "user"
+	switch role {
+	case models.RoleSeller:
+		accountKey = "Seller"
+	case models.RoleAdmin:
+		accountKey = "admin"
+	}
+	util.Response(c, "Login successful", 200, gin.H{
+		accountKey:      account.response(),
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	}, nil)
+}
+
+// acceptTOTP validates code and records its time step. Codes from a step at or before
+// the last accepted one are refused, so a code cannot be used twice.
+func acceptTOTP(twoFactor *models.TwoFactor, code string) bool {
+	counter, ok := totp.Validate(twoFactor.TOTPSecret, code, time.Now())
+	if !ok || counter <= twoFactor.TOTPLastCounter {
+		return false
+	}
+	twoFactor.TOTPLastCounter = counter
+	return true
+}
+
+// newRecoveryCodes replaces an account's recovery codes and returns the plaintext codes
+func (u *HTTPHandler) newRecoveryCodes(accountID uint) ([]string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]*models.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		records = append(records, &models.RecoveryCode{
+			AccountID: accountID,
+			CodeHash:  util.HashToken(raw),
+		})
+	}
+
+	if err := u.Repository.ReplaceRecoveryCodes(accountID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode accepts codes typed with or without the dash and in any case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -1,48 +1,52 @@
 package api
 
 import (
-	"e-commerce/internal/middleware"
 	"e-commerce/internal/models"
 	"e-commerce/internal/util"
+	"errors"
 
-	"os"
+	"log"
 	"strconv"
 	"strings"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Create User
+// Create User. Existing accounts add the role from a signed-in session instead.
 func (u *HTTPHandler) CreateUser(c *gin.Context) {
-	var user *models.User
-	if err := c.ShouldBind(&user); err != nil {
+	var request *models.CreateUserRequest
+	if err := c.ShouldBind(&request); err != nil {
 		util.Response(c, "invalid request", 400, err.Error(), nil)
 		return
 	}
 
-	//check if user already exists
-	_, err := u.Repository.FindUserByEmail(user.Email)
-	if err == nil {
-		util.Response(c, "User already exists", 400, "Bad request body", nil)
+	identity, ok := u.signupAccount(c, models.RoleUser, &request.SignupRequest)
+	if !ok {
 		return
 	}
-
-	// Hash the password
-	hashedPassword, err := util.HashPassword(user.Password)
-	if err != nil {
-		util.Response(c, "Internal server error", 500, err.Error(), nil)
+	if identity == nil {
+		util.Response(c, "User created, check your email to verify your account", 200, nil, nil)
 		return
 	}
-	user.Password = hashedPassword
 
-	err = u.Repository.CreateUser(user)
-	if err != nil {
+	user := &models.User{
+		AccountID: identity.ID,
+		Account:   identity,
+	}
+	if err := u.Repository.CreateUser(user); err != nil {
 		util.Response(c, "User not created", 500, err.Error(), nil)
 		return
 	}
-	util.Response(c, "User created", 200, nil, nil)
+
+	u.recordPassword(user.AccountID, identity.Password)
+
+	// The account exists even if the email fails; the user can ask for a resend
+	if err := u.sendVerificationEmail(models.RoleUser, u.userAccount(user)); err != nil {
+		log.Printf("send verification email errors: %v\n", err)
+	}
+
+	util.Response(c, "User created, check your email to verify your account", 200, nil, nil)
 
 }
 
@@ -67,53 +71,58 @@ func (u *HTTPHandler) LoginUser(c *gin.Context) {
 		return
 	}
 
+	if !u.loginAllowed(c, loginRequest.Email) {
+		return
+	}
+
 	user, err := u.Repository.FindUserByEmail(loginRequest.Email)
 	if err != nil {
-		util.Response(c, "Email does not exist", 404, err.Error(), nil)
+		compareDummyPassword(loginRequest.Password)
+		u.loginFailed(c, models.RoleUser, loginRequest.Email)
+		util.Response(c, invalidCredentials, 401, nil, nil)
 		return
 	}
 
 	// compare password
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginRequest.Password))
+	err = bcrypt.CompareHashAndPassword([]byte(user.Account.Password), []byte(loginRequest.Password))
 	if err != nil {
-		util.Response(c, "Invalid password", 400, err.Error(), nil)
+		u.loginFailed(c, models.RoleUser, loginRequest.Email)
+		util.Response(c, invalidCredentials, 401, nil, nil)
 		return
 	}
+	rehashPassword(&user.Account.Password, loginRequest.Password, u.userAccount(user).Save)
 
-	accessClaims, refreshClaims := middleware.GenerateClaims(user.Email)
-
-	secret := os.Getenv("JWT_SECRET")
-
-	accessToken, err := middleware.GenerateToken(jwt.SigningMethodHS256, accessClaims, &secret)
-	if err != nil {
-		util.Response(c, "Error generating access token", 500, err.Error(), nil)
+	// With two-factor enabled the password only earns a challenge token
+	if user.Account.TOTPEnabled {
+		u.requireSecondFactor(c, models.RoleUser, user.AccountID)
 		return
 	}
+	u.loginSucceeded(loginRequest.Email)
 
-	refreshToken, err := middleware.GenerateToken(jwt.SigningMethodHS256, refreshClaims, &secret)
+	accessToken, refreshToken, err := u.startSession(c, u.userAccount(user))
 	if err != nil {
-		util.Response(c, "Error generating refresh token", 500, err.Error(), nil)
+		util.Response(c, "Error generating tokens", 500, err.Error(), nil)
 		return
 	}
 
-	/* notifications, err := u.Repository.GetNotificationsByUserID(user.ID)
+	// The session already exists, so failing to load notifications must not fail the login
+	notifications, err := u.Repository.ListNotifications(models.RoleUser, user.ID, true, notificationPageSize)
 	if err != nil {
-		util.Response(c, "Internal server error", 500, err.Error(), nil)
-		return
-	} */
+		log.Printf("list notifications errors: %v\n", err)
+	}
 
 	c.Header("access_token", *accessToken)
 	c.Header("refresh_token", *refreshToken)
 
 	util.Response(c, "Login successful", 200, gin.H{
-		"user": user,
-		//"notification_details": notifications,
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+		"user":                 models.NewUserResponse(user),
+		"notification_details": models.NewNotificationResponses(notifications),
+		"access_token":         accessToken,
+		"refresh_token":        refreshToken,
 	}, nil)
 }
 
-// get all products
+// get a page of the catalogue, filtered and sorted by the query string
 func (u *HTTPHandler) GetAllProducts(c *gin.Context) {
 	//get useer id from context
 	_, err := u.GetUserFromContext(c)
@@ -122,13 +131,19 @@ func (u *HTTPHandler) GetAllProducts(c *gin.Context) {
 		return
 	}
 
-	products, err := u.Repository.GetAllProducts()
+	query, ok := bindProductQuery(c, models.ProductSortNewest)
+	if !ok {
+		return
+	}
+
+	products, total, err := u.Repository.ListProducts(query)
 	if err != nil {
 		util.Response(c, "Internal server error", 500, err.Error(), nil)
 		return
 	}
 	util.Response(c, "Products fetched", 200, gin.H{
-		"products": products,
+		"products": models.NewProductResponses(products),
+		"meta":     models.NewPageMeta(total, query.Page, query.Limit),
 	}, nil)
 }
 
@@ -142,26 +157,36 @@ func (u *HTTPHandler) AddProductToCart(c *gin.Context) {
 	}
 
 	//bind request to struct
-	var cart *models.IndividualItemInCart
-	if err := c.ShouldBind(&cart); err != nil {
+	var request *models.CartItemRequest
+	if err := c.ShouldBind(&request); err != nil {
 		util.Response(c, "invalid request", 400, err.Error(), nil)
 		return
 	}
 
 	//validate request
-	product, err := u.Repository.GetProductByID(cart.ProductID)
+	product, err := u.Repository.GetProductByID(request.ProductID)
 	if err != nil {
 		util.Response(c, "Product not found", 404, err.Error(), nil)
 		return
 	}
 
+	//check if product is on sale
+	if !product.Status {
+		util.Response(c, "Product is not available", 400, nil, nil)
+		return
+	}
+
 	//check if product quantity is less
-	if cart.Quantity > product.Quantity {
+	if request.Quantity > product.Quantity {
 		util.Response(c, "Product quantity is less", 400, nil, nil)
 		return
 	}
 
-	cart.UserID = user.ID
+	cart := &models.IndividualItemInCart{
+		UserID:    user.ID,
+		ProductID: product.ID,
+		Quantity:  request.Quantity,
+	}
 
 	err = u.Repository.AddProductToCart(cart)
 	if err != nil {
@@ -171,6 +196,25 @@ func (u *HTTPHandler) AddProductToCart(c *gin.Context) {
 	util.Response(c, "Product added to cart", 200, nil, nil)
 }
 
+// bindProductQuery reads the catalogue filters, sort and page from the query string,
+// using defaultSort when the client does not choose an order
+func bindProductQuery(c *gin.Context, defaultSort models.ProductSort) (*models.ProductQuery, bool) {
+	query := &models.ProductQuery{}
+	if err := c.ShouldBindQuery(query); err != nil {
+		util.Response(c, "invalid query", 400, err.Error(), nil)
+		return nil, false
+	}
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		util.Response(c, "min_price must not be greater than max_price", 400, nil, nil)
+		return nil, false
+	}
+	if query.Sort == "" {
+		query.Sort = defaultSort
+	}
+	query.Normalize()
+	return query, true
+}
+
 // get all products in cart
 func (u *HTTPHandler) ViewCart(c *gin.Context) {
 	// Get user ID from context
@@ -207,7 +251,7 @@ func (u *HTTPHandler) ViewCart(c *gin.Context) {
 		}
 		cartTotal.Cart[i] = &models.CartItem{
 			CartID:   cartItem.ID,
-			Product:  product,
+			Product:  models.NewProductResponse(product),
 			Quantity: cartItem.Quantity,
 		}
 		total += float64(cartItem.Quantity) * product.Price
@@ -232,6 +276,19 @@ func (u *HTTPHandler) PlaceOrder(c *gin.Context) {
 		return
 	}
 
+	var request *models.PlaceOrderRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	// Get the shipping address from the address book
+	address, err := u.Repository.GetAddressByID(request.AddressID)
+	if err != nil || address.UserID != user.ID {
+		util.Response(c, "Address not found", 404, nil, nil)
+		return
+	}
+
 	// Get products in cart
 	cartItems, err := u.Repository.GetCartsByUserID(user.ID)
 	if err != nil {
@@ -244,15 +301,24 @@ func (u *HTTPHandler) PlaceOrder(c *gin.Context) {
 		return
 	}
 
-	// Calculate total and prepare order items
-	var total float64
-	var orderItems []*models.OrderItem
+	// Prepare one order per seller, so each seller accepts, declines and completes only
+	// their own items
+	var orders []*models.Order
+	sellerOrders := make(map[uint]*models.Order)
+	products := make(map[uint]*models.Product, len(cartItems))
 	for _, cartItem := range cartItems {
 		product, err := u.Repository.GetProductByID(cartItem.ProductID)
 		if err != nil {
 			util.Response(c, "Error fetching product details", 500, err.Error(), nil)
 			return
 		}
+		products[product.ID] = product
+
+		// Check if the product is still on sale
+		if !product.Status {
+			util.Response(c, "Product is not available", 400, product.Title+" is no longer on sale", nil)
+			return
+		}
 
 		// Check if the product is out of stock
 		if cartItem.Quantity > product.Quantity {
@@ -260,30 +326,39 @@ func (u *HTTPHandler) PlaceOrder(c *gin.Context) {
 			return
 		}
 
-		// Calculate total price
-		total += float64(cartItem.Quantity) * product.Price
+		order, ok := sellerOrders[product.SellerID]
+		if !ok {
+			order = &models.Order{
+				UserID: user.ID,
+				Status: "PLACED",
+				// Snapshot the address so later address book edits do not move the parcel
+				ShippingAddress: address.PostalAddress,
+			}
+			sellerOrders[product.SellerID] = order
+			orders = append(orders, order)
+		}
 
-		// Prepare order item
-		orderItems = append(orderItems, &models.OrderItem{
+		// Calculate total price and prepare the order item
+		order.Total += float64(cartItem.Quantity) * product.Price
+		order.Items = append(order.Items, &models.OrderItem{
 			ProductID: cartItem.ProductID,
 			Quantity:  cartItem.Quantity,
 		})
 	}
 
-	// Prepare the order
-	order := &models.Order{
-		UserID: user.ID,
-		Total:  total,
-		Status: "PLACED",
-		Items:  orderItems,
+	// Save the orders, take their units out of stock and clear the cart within a transaction
+	err = u.Repository.CreateOrders(orders)
+	if errors.Is(err, models.ErrInsufficientStock) {
+		util.Response(c, "Product out of stock", 400, "Product is out of stock", nil)
+		return
 	}
-
-	// Save the order and clear the cart within a transaction
-	err = u.Repository.CreateOrder(order)
 	if err != nil {
 		util.Response(c, "Error creating order", 500, err.Error(), nil)
 		return
 	}
+	for _, order := range orders {
+		u.notify(u.orderPlacedNotifications(order, products)...)
+	}
 
 	util.Response(c, "Order placed successfully", 200, nil, nil)
 }
@@ -298,37 +373,36 @@ func (u *HTTPHandler) EditCart(c *gin.Context) {
 	}
 
 	// Bind request to struct
-	var cart *models.IndividualItemInCart
-	if err := c.ShouldBind(&cart); err != nil {
+	var request *models.CartItemRequest
+	if err := c.ShouldBind(&request); err != nil {
 		util.Response(c, "invalid request", 400, err.Error(), nil)
 		return
 	}
 
 	// Get cart by user id
-	shoppingCart, err := u.Repository.GetCartItemByProductID(cart.ProductID)
+	shoppingCart, err := u.Repository.GetCartItemByProductID(user.ID, request.ProductID)
 	if err != nil {
 		util.Response(c, "Cart not found", 404, err.Error(), nil)
 		return
 	}
 
 	// Validate request
-	product, err := u.Repository.GetProductByID(cart.ProductID)
+	product, err := u.Repository.GetProductByID(request.ProductID)
 	if err != nil {
 		util.Response(c, "Product not found", 404, err.Error(), nil)
 		return
 	}
 
 	// Check if product quantity is less
-	if product.Quantity < cart.Quantity {
+	if product.Quantity < request.Quantity {
 		util.Response(c, "Product quantity is less", 400, nil, nil)
 		return
 	}
 
 	// Update cart
-	cart.UserID = user.ID
-	cart.ID = shoppingCart.ID
+	shoppingCart.Quantity = request.Quantity
 
-	err = u.Repository.AddProductToCart(cart)
+	err = u.Repository.AddProductToCart(shoppingCart)
 	if err != nil {
 		util.Response(c, "Internal server error", 500, err.Error(), nil)
 		return
@@ -339,7 +413,7 @@ func (u *HTTPHandler) EditCart(c *gin.Context) {
 // delete product from cart
 func (u *HTTPHandler) DeleteProductFromCart(c *gin.Context) {
 	// Get user id from context
-	_, err := u.GetUserFromContext(c)
+	user, err := u.GetUserFromContext(c)
 	if err != nil {
 		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
 		return
@@ -354,7 +428,7 @@ func (u *HTTPHandler) DeleteProductFromCart(c *gin.Context) {
 	}
 
 	// Validate request
-	shoppingCart, err := u.Repository.GetCartItemByProductID(uint(productIDInt))
+	shoppingCart, err := u.Repository.GetCartItemByProductID(user.ID, uint(productIDInt))
 	if err != nil {
 		util.Response(c, "Product not found", 404, err.Error(), nil)
 		return
@@ -371,7 +445,7 @@ func (u *HTTPHandler) DeleteProductFromCart(c *gin.Context) {
 // get products by id
 func (u *HTTPHandler) GetProductByID(c *gin.Context) {
 	// Get user id from context
-	_, err := u.GetUserFromContext(c)
+	user, err := u.GetUserFromContext(c)
 	if err != nil {
 		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
 		return
@@ -390,8 +464,14 @@ func (u *HTTPHandler) GetProductByID(c *gin.Context) {
 		util.Response(c, "Product not found", 404, err.Error(), nil)
 		return
 	}
+
+	// History is a convenience, so failing to record the view does not fail the request
+	if err := u.Repository.RecordProductView(user.ID, product.ID); err != nil {
+		log.Printf("record product view errors: %v\n", err)
+	}
+
 	util.Response(c, "Product fetched", 200, gin.H{
-		"product": product,
+		"product": models.NewProductResponse(product),
 	}, nil)
 }
 
@@ -410,19 +490,14 @@ func (u *HTTPHandler) ViewOrders(c *gin.Context) {
 		return
 	}
 
-	//prepare response structure
-	var orderDetails []models.Order
 	for _, order := range orders {
-		orderItems, err := u.Repository.GetOrderItemsByOrderID(order.ID)
-		if err != nil {
+		if order.Items, err = u.Repository.GetOrderItemsByOrderID(order.ID); err != nil {
 			util.Response(c, "Internal server error", 500, err.Error(), nil)
 			return
 		}
-		order.Items = orderItems
-		orderDetails = append(orderDetails, *order)
 	}
 
 	util.Response(c, "Orders fetched", 200, gin.H{
-		"orders": orderDetails,
+		"orders": models.NewOrderResponses(orders),
 	}, nil)
 }
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const EmailVerificationTokenValidity = time.Hour * 48
+
+// Verify User email
+func (u *HTTPHandler) VerifyUserEmail(c *gin.Context) {
+	u.verifyEmail(c, models.RoleUser)
+}
+
+// Verify Seller email
+func (u *HTTPHandler) VerifySellerEmail(c *gin.Context) {
+	u.verifyEmail(c, models.RoleSeller)
+}
+
+// Resend User verification email
+func (u *HTTPHandler) ResendUserVerification(c *gin.Context) {
+	u.resendVerification(c, models.RoleUser)
+}
+
+// Resend Seller verification email
+func (u *HTTPHandler) ResendSellerVerification(c *gin.Context) {
+	u.resendVerification(c, models.RoleSeller)
+}
+
+// sendVerificationEmail issues a fresh verification token and mails the confirmation link
+func (u *HTTPHandler) sendVerificationEmail(role models.Role, account *account) error {
+	token, err := util.GenerateRandomToken()
+	if err != nil {
+		return err
+	}
+
+	err = u.Repository.CreateOneTimeToken(&models.OneTimeToken{
+		Purpose:   models.PurposeEmailVerification,
+		Role:      role,
+		AccountID: account.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(EmailVerificationTokenValidity),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := appURL(fmt.Sprintf("/%s/email/verify?token=%s", role, url.QueryEscape(token)))
+	return u.Mailer.Send(&models.MailMessage{
+		To:      account.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s\n",
+			account.FirstName, EmailVerificationTokenValidity, link),
+	})
+}
+
+// verifyEmail redeems a verification token
+func (u *HTTPHandler) verifyEmail(c *gin.Context, role models.Role) {
+	var request *models.VerifyEmailRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	token, err := u.Repository.ConsumeOneTimeToken(models.PurposeEmailVerification, util.HashToken(request.Token))
+	if err != nil || token.Role != role {
+		util.Response(c, "Invalid or expired verification token", 400, nil, nil)
+		return
+	}
+
+	if err := u.markAccountVerified(token.AccountID); err != nil {
+		util.Response(c, "Error verifying email", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Email verified", 200, nil, nil)
+}
+
+// resendVerification mails a new confirmation link to an unverified account. Like
+// forgotPassword it answers the same way for unknown emails.
+func (u *HTTPHandler) resendVerification(c *gin.Context, role models.Role) {
+	var request *models.ResendVerificationRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	const message = "If the email is registered and unverified, a confirmation link has been sent"
+
+	account, err := u.accountByEmail(role, strings.TrimSpace(request.Email))
+	if err != nil || account.EmailVerified {
+		util.Response(c, message, 200, nil, nil)
+		return
+	}
+
+	if err := u.sendVerificationEmail(role, account); err != nil {
+		util.Response(c, "Error sending verification email", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, message, 200, nil, nil)
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/util"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// view the wishlist with current prices and stock
+func (u *HTTPHandler) ViewWishlist(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	wishlist, err := u.Repository.GetWishlist(user.ID)
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Wishlist fetched", 200, models.NewWishlistResponse(wishlist), nil)
+}
+
+// save a product to the wishlist
+func (u *HTTPHandler) AddToWishlist(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	var request *models.WishlistItemRequest
+	if err := c.ShouldBind(&request); err != nil {
+		util.Response(c, "invalid request", 400, err.Error(), nil)
+		return
+	}
+
+	if _, err := u.Repository.GetProductByID(request.ProductID); err != nil {
+		util.Response(c, "Product not found", 404, err.Error(), nil)
+		return
+	}
+
+	if err := u.Repository.AddToWishlist(user.ID, request.ProductID); err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "Product saved to wishlist", 200, nil, nil)
+}
+
+// remove a product from the wishlist
+func (u *HTTPHandler) RemoveFromWishlist(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	productID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid product ID", 400, err.Error(), nil)
+		return
+	}
+
+	err = u.Repository.RemoveFromWishlist(user.ID, productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		util.Response(c, "Product not in wishlist", 404, nil, nil)
+		return
+	}
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "Product removed from wishlist", 200, nil, nil)
+}
+
+// move a product from the wishlist into the cart, one unit at a time
+func (u *HTTPHandler) MoveWishlistItemToCart(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	productID, err := util.ConvertStringToUint(c.Param("id"))
+	if err != nil {
+		util.Response(c, "Invalid product ID", 400, err.Error(), nil)
+		return
+	}
+
+	product, err := u.Repository.GetProductByID(productID)
+	if err != nil {
+		util.Response(c, "Product not found", 404, err.Error(), nil)
+		return
+	}
+
+	// Add to the existing cart line, if there is one
+	cart, err := u.Repository.GetCartItemByProductID(user.ID, productID)
+	if err != nil {
+		cart = &models.IndividualItemInCart{UserID: user.ID, ProductID: productID}
+	}
+	cart.Quantity++
+
+	if !product.Status || cart.Quantity > product.Quantity {
+		util.Response(c, "Product quantity is less", 400, nil, nil)
+		return
+	}
+
+	err = u.Repository.MoveWishlistItemToCart(user.ID, cart)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		util.Response(c, "Product not in wishlist", 404, nil, nil)
+		return
+	}
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "Product moved to cart", 200, nil, nil)
+}
+
+// ShareWishlist creates a read-only link to the wishlist. Sharing again replaces the
+// previous link, which stops working.
+func (u *HTTPHandler) ShareWishlist(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	token, err := util.GenerateRandomToken()
+	if err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	tokenHash := util.HashToken(token)
+	if err := u.Repository.SetWishlistShareToken(user.ID, &tokenHash); err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+
+	util.Response(c, "Wishlist shared. Copy the link now, it will not be shown again", 200, gin.H{
+		"link":  appURL("/wishlist/shared/" + token),
+		"token": token,
+	}, nil)
+}
+
+// stop sharing the wishlist
+func (u *HTTPHandler) UnshareWishlist(c *gin.Context) {
+	user, err := u.GetUserFromContext(c)
+	if err != nil {
+		util.Response(c, "Error getting user from context", 500, err.Error(), nil)
+		return
+	}
+
+	if err := u.Repository.SetWishlistShareToken(user.ID, nil); err != nil {
+		util.Response(c, "Internal server error", 500, err.Error(), nil)
+		return
+	}
+	util.Response(c, "Wishlist no longer shared", 200, nil, nil)
+}
+
+// view a shared wishlist. Anyone with the link can read it; nothing about the owner is shown.
+func (u *HTTPHandler) ViewSharedWishlist(c *gin.Context) {
+	wishlist, err := u.Repository.GetWishlistByShareToken(util.HashToken(c.Param("token")))
+	if err != nil {
+		util.Response(c, "Wishlist not found", 404, nil, nil)
+		return
+	}
+
+	util.Response(c, "Wishlist fetched", 200, models.NewWishlistResponse(wishlist), nil)
+}
@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"e-commerce/internal/models"
+	"e-commerce/internal/ports"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LogMailer writes every message to the application log instead of sending it
+type LogMailer struct {
+	From string
+}
+
+// FileMailer writes every message as an .eml file in Dir, which is handy for local testing
+type FileMailer struct {
+	From string
+	Dir  string
+}
+
+var fileCounter uint64
+
+// New returns a FileMailer when MAIL_DIR is set and a LogMailer otherwise
+func New() ports.Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@e-commerce.local"
+	}
+
+	if dir := os.Getenv("MAIL_DIR"); dir != "" {
+		return &FileMailer{From: from, Dir: dir}
+	}
+	return &LogMailer{From: from}
+}
+
+func (m *LogMailer) Send(message *models.MailMessage) error {
+	log.Printf("mail from %s to %s: %s\n%s\n", m.From, message.To, message.Subject, message.Body)
+	return nil
+}
+
+func (m *FileMailer) Send(message *models.MailMessage) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%d.eml", now.Format("20060102T150405.000000000"), atomic.AddUint64(&fileCounter, 1))
+	content := strings.Join([]string{
+		"From: " + m.From,
+		"To: " + message.To,
+		"Subject: " + message.Subject,
+		"Date: " + now.Format(time.RFC1123Z),
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		message.Body,
+	}, "\r\n")
+
+	return os.WriteFile(filepath.Join(m.Dir, name), []byte(content), 0o600)
+}
@@ -4,87 +4,141 @@ import (
 	"e-commerce/internal/models"
 	"log"
 	"net/http"
-	"os"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthorizeSeller(findSellerByEmail func(string) (*models.Seller, error), tokenInBlacklist func(*string) bool) gin.HandlerFunc {
+// TouchSession reports whether the session with this id is still active and records activity on it
+type TouchSession func(role models.Role, sessionID string) bool
+
+// AuthorizeSeller lets through access tokens issued for the seller role of an account
+// that still holds a seller membership
+func AuthorizeSeller(keys *KeySet, getSellerByAccountID func(uint) (*models.Seller, error), tokenInBlacklist func(string) bool, touchSession TouchSession) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		var seller *models.Seller
-		var errors error
-		secret := os.Getenv("JWT_SECRET")
-		accToken := GetTokenFromHeader(c)
-		accessToken, accessClaims, err := AuthorizeToken(&accToken, &secret)
-		if err != nil {
-			log.Printf("authorize access token errors: %s\n", err.Error())
-			RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"unauthorized"})
+		accessToken, accessClaims, accountID, ok := authorizeAccessToken(c, keys, models.RoleSeller, tokenInBlacklist, touchSession)
+		if !ok {
 			return
 		}
 
-		if tokenInBlacklist(&accessToken.Raw) || IsTokenExpired(accessClaims) {
-			RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"unauthorized"})
+		seller, err := getSellerByAccountID(accountID)
+		if err != nil {
+			log.Printf("find Seller by id errors: %v\n", err)
+			RespondAndAbort(c, "", http.StatusNotFound, nil, []string{"Seller not found"})
 			return
 		}
 
-		if email, ok := accessClaims["user_email"].(string); ok {
-			if seller, errors = findSellerByEmail(email); errors != nil {
-				log.Printf("find Seller by email errors: %v\n", err)
-				RespondAndAbort(c, "", http.StatusNotFound, nil, []string{"Seller not found"})
-				return
-			}
-		} else {
-			log.Printf("Seller email is not string\n")
-			RespondAndAbort(c, "", http.StatusInternalServerError, nil, []string{"internal server errors"})
+		// tokens issued before the account signed out everywhere are no longer valid
+		if seller.Account == nil || GetTokenVersion(accessClaims) != seller.Account.TokenVersion {
+			RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"unauthorized"})
 			return
 		}
 
 		// set the Seller and token as context parameters.
 		c.Set("Seller", seller)
 		c.Set("access_token", accessToken.Raw)
+		c.Set("access_claims", accessClaims)
 
 		// calling next handler
 		c.Next()
 	}
 }
 
-func AuthorizeUser(findUserByEmail func(string) (*models.User, error), tokenInBlacklist func(*string) bool) gin.HandlerFunc {
+// AuthorizeUser lets through access tokens issued for the buyer role of an account
+// that still holds a buyer membership
+func AuthorizeUser(keys *KeySet, getUserByAccountID func(uint) (*models.User, error), tokenInBlacklist func(string) bool, touchSession TouchSession) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		var user *models.User
-		var errors error
-		secret := os.Getenv("JWT_SECRET")
-		accToken := GetTokenFromHeader(c)
-		accessToken, accessClaims, err := AuthorizeToken(&accToken, &secret)
+		accessToken, accessClaims, accountID, ok := authorizeAccessToken(c, keys, models.RoleUser, tokenInBlacklist, touchSession)
+		if !ok {
+			return
+		}
+
+		user, err := getUserByAccountID(accountID)
 		if err != nil {
-			log.Printf("authorize access token errors: %s\n", err.Error())
+			log.Printf("find user by id errors: %v\n", err)
+			RespondAndAbort(c, "", http.StatusNotFound, nil, []string{"user not found"})
+			return
+		}
+
+		// tokens issued before the account signed out everywhere are no longer valid
+		if user.Account == nil || GetTokenVersion(accessClaims) != user.Account.TokenVersion {
 			RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"unauthorized"})
 			return
 		}
 
-		if tokenInBlacklist(&accessToken.Raw) || IsTokenExpired(accessClaims) {
+		// set the user and token as context parameters.
+		c.Set("user", user)
+		c.Set("access_token", accessToken.Raw)
+		c.Set("access_claims", accessClaims)
+
+		// calling next handler
+		c.Next()
+	}
+}
+
+// AuthorizeAdmin lets through access tokens issued for the admin role of an account
+// that still holds an admin membership
+func AuthorizeAdmin(keys *KeySet, getAdminByAccountID func(uint) (*models.Admin, error), tokenInBlacklist func(string) bool, touchSession TouchSession) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		accessToken, accessClaims, accountID, ok := authorizeAccessToken(c, keys, models.RoleAdmin, tokenInBlacklist, touchSession)
+		if !ok {
+			return
+		}
+
+		admin, err := getAdminByAccountID(accountID)
+		if err != nil {
+			log.Printf("find admin by id errors: %v\n", err)
 			RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"unauthorized"})
 			return
 		}
 
-		if email, ok := accessClaims["user_email"].(string); ok {
-			if user, errors = findUserByEmail(email); errors != nil {
-				log.Printf("find user by email errors: %v\n", err)
-				RespondAndAbort(c, "", http.StatusNotFound, nil, []string{"user not found"})
-				return
-			}
-		} else {
-			log.Printf("user email is not string\n")
-			RespondAndAbort(c, "", http.StatusInternalServerError, nil, []string{"internal server errors"})
+		// tokens issued before the account signed out everywhere are no longer valid
+		if admin.Account == nil || GetTokenVersion(accessClaims) != admin.Account.TokenVersion {
+			RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"unauthorized"})
 			return
 		}
 
-		// set the user and token as context parameters.
-		c.Set("user", user)
+		// set the admin and token as context parameters.
+		c.Set("admin", admin)
 		c.Set("access_token", accessToken.Raw)
+		c.Set("access_claims", accessClaims)
 
 		// calling next handler
 		c.Next()
 	}
 }
+
+// authorizeAccessToken verifies the bearer token was minted as an access token for role,
+// from a session that is still active, and returns its subject, the account ID. It aborts the request
+// and returns false otherwise.
+func authorizeAccessToken(c *gin.Context, keys *KeySet, role models.Role, tokenInBlacklist func(string) bool, touchSession TouchSession) (*jwt.Token, jwt.MapClaims, uint, bool) {
+	accToken := GetTokenFromHeader(c)
+	accessToken, accessClaims, err := AuthorizeToken(&accToken, keys)
+	if err != nil {
+		log.Printf("authorize access token errors: %s\n", err.Error())
+		RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"unauthorized"})
+		return nil, nil, 0, false
+	}
+
+	if err := ValidateClaims(accessClaims, AccessTokenType, role); err != nil {
+		log.Printf("validate access token claims errors: %s\n", err.Error())
+		RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"unauthorized"})
+		return nil, nil, 0, false
+	}
+
+	if tokenID, _ := accessClaims["jti"].(string); tokenInBlacklist(tokenID) {
+		RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"unauthorized"})
+		return nil, nil, 0, false
+	}
+
+	if sessionID := GetSessionID(accessClaims); sessionID == "" || !touchSession(role, sessionID) {
+		RespondAndAbort(c, "", http.StatusUnauthorized, nil, []string{"session has been revoked"})
+		return nil, nil, 0, false
+	}
+
+	subject, _ := GetSubject(accessClaims)
+	return accessToken, accessClaims, subject, true
+}
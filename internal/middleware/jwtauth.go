@@ -1,48 +1,123 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"e-commerce/internal/models"
 	"e-commerce/internal/util"
+	"encoding/hex"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"log"
+	"os"
+	"strconv"
 	"time"
 )
 
-const AccessTokenValidity = time.Hour * 24
-const RefreshTokenValidity = time.Hour * 24
+const AccessTokenValidity = time.Minute * 15
+const RefreshTokenValidity = time.Hour * 24 * 30
+const ChallengeTokenValidity = time.Minute * 5
+
+// token_type claim values, so a refresh token can never be used as an access token
+const (
+	AccessTokenType    = "access"
+	RefreshTokenType   = "refresh"
+	ChallengeTokenType = "mfa_challenge"
+)
 
 type Claims struct {
 	UserEmail string `json:"email"`
 	jwt.StandardClaims
 }
 
-func GenerateClaims(email string) (jwt.MapClaims, jwt.MapClaims) {
-	log.Println("generate  claim function", email)
+// Issuer returns the iss claim stamped on and required from every token
+func Issuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "e-commerce"
+}
+
+// Audience returns the aud claim stamped on and required from every token
+func Audience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "e-commerce-api"
+}
+
+// Subject identifies who a token is issued to
+type Subject struct {
+	Role  models.Role
+	ID    uint
+	Email string
+	// Version is the account's token version at the time of issue
+	Version uint
+}
+
+// GenerateClaims returns the access and refresh claims for a session.
+// sessionID is the refresh-token family shared by every token rotated from the same login.
+func GenerateClaims(subject Subject, sessionID string) (jwt.MapClaims, jwt.MapClaims) {
+	log.Println("generate  claim function", subject.Email)
+	now := time.Now()
 	accessClaims := jwt.MapClaims{
-		"user_email": email,
-		"exp":        time.Now().Add(AccessTokenValidity).Unix(),
+		"sub":        strconv.FormatUint(uint64(subject.ID), 10),
+		"role":       string(subject.Role),
+		"iss":        Issuer(),
+		"aud":        Audience(),
+		"jti":        NewTokenID(),
+		"iat":        now.Unix(),
+		"sid":        sessionID,
+		"ver":        subject.Version,
+		"user_email": subject.Email,
+		"token_type": AccessTokenType,
+		"exp":        now.Add(AccessTokenValidity).Unix(),
 	}
 
 	refreshClaims := jwt.MapClaims{
-		"exp": time.Now().Add(RefreshTokenValidity).Unix(),
-		"sub": 1,
+		"sub":        strconv.FormatUint(uint64(subject.ID), 10),
+		"role":       string(subject.Role),
+		"iss":        Issuer(),
+		"aud":        Audience(),
+		"jti":        NewTokenID(),
+		"iat":        now.Unix(),
+		"sid":        sessionID,
+		"ver":        subject.Version,
+		"token_type": RefreshTokenType,
+		"exp":        now.Add(RefreshTokenValidity).Unix(),
 	}
 
 	return accessClaims, refreshClaims
 }
 
-// GenerateToken generates only an access token
-func GenerateToken(signMethod *jwt.SigningMethodHMAC, claims jwt.MapClaims, secret *string) (*string, error) {
-	// Create a new token object, specifying signing method and the claims
-	// you would like it to contain.
-	token := jwt.NewWithClaims(signMethod, claims)
-	// Sign and get the complete encoded token as a string using the secret
-	tokenString, err := token.SignedString([]byte(*secret))
-	if err != nil {
-		return nil, err
+// GenerateChallengeClaims returns the claims of the intermediate token handed out when
+// the password is correct but a second factor is still required
+func GenerateChallengeClaims(role models.Role, subject uint) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub":        strconv.FormatUint(uint64(subject), 10),
+		"role":       string(role),
+		"iss":        Issuer(),
+		"aud":        Audience(),
+		"jti":        NewTokenID(),
+		"iat":        now.Unix(),
+		"token_type": ChallengeTokenType,
+		"exp":        now.Add(ChallengeTokenValidity).Unix(),
 	}
-	return &tokenString, nil
+}
+
+// NewTokenID returns a random identifier suitable for a jti or token family
+func NewTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// GenerateToken signs the claims with the active key of the key set
+func GenerateToken(keys *KeySet, claims jwt.MapClaims) (*string, error) {
+	return keys.Sign(claims)
 }
 
 // GetTokenFromHeader returns the token string in the authorization header
@@ -54,28 +129,23 @@ func GetTokenFromHeader(c *gin.Context) string {
 	return ""
 }
 
-// verifyAccessToken verifies a token
-func verifyToken(tokenString *string, claims jwt.MapClaims, secret *string) (*jwt.Token, error) {
+// verifyToken verifies a token against the key named by its kid header
+func verifyToken(tokenString *string, claims jwt.MapClaims, keys *KeySet) (*jwt.Token, error) {
 	parser := &jwt.Parser{SkipClaimsValidation: true}
-	return parser.ParseWithClaims(*tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(*secret), nil
-	})
+	return parser.ParseWithClaims(*tokenString, claims, keys.keyFunc)
 }
 
 // AuthorizeToken check if a refresh token is valid
-func AuthorizeToken(token *string, secret *string) (*jwt.Token, jwt.MapClaims, error) {
-	if token != nil && *token != "" && secret != nil && *secret != "" {
+func AuthorizeToken(token *string, keys *KeySet) (*jwt.Token, jwt.MapClaims, error) {
+	if token != nil && *token != "" && keys != nil {
 		claims := jwt.MapClaims{}
-		token, err := verifyToken(token, claims, secret)
+		token, err := verifyToken(token, claims, keys)
 		if err != nil {
 			return nil, nil, err
 		}
 		return token, claims, nil
 	}
-	return nil, nil, fmt.Errorf("empty token or secret")
+	return nil, nil, fmt.Errorf("empty token or key set")
 }
 
 // IsTokenExpired checks if token has expired
@@ -86,6 +156,74 @@ func IsTokenExpired(claims jwt.MapClaims) bool {
 	return true
 }
 
+// GetExpiry returns the time held in the exp claim
+func GetExpiry(claims jwt.MapClaims) time.Time {
+	if exp, ok := claims["exp"].(float64); ok {
+		return time.Unix(int64(exp), 0)
+	}
+	return time.Time{}
+}
+
+// GetSessionID returns the sid claim
+func GetSessionID(claims jwt.MapClaims) string {
+	sid, _ := claims["sid"].(string)
+	return sid
+}
+
+// GetTokenVersion returns the ver claim
+func GetTokenVersion(claims jwt.MapClaims) uint {
+	if ver, ok := claims["ver"].(float64); ok && ver >= 0 {
+		return uint(ver)
+	}
+	return 0
+}
+
+// IsTokenType checks the token_type claim
+func IsTokenType(claims jwt.MapClaims, tokenType string) bool {
+	t, ok := claims["token_type"].(string)
+	return ok && t == tokenType
+}
+
+// ValidateClaims checks that a token is unexpired, of the given type, minted by us
+// for our audience, and issued to the given role
+func ValidateClaims(claims jwt.MapClaims, tokenType string, role models.Role) error {
+	if IsTokenExpired(claims) {
+		return fmt.Errorf("token has expired")
+	}
+	if !IsTokenType(claims, tokenType) {
+		return fmt.Errorf("token is not an %s token", tokenType)
+	}
+	if !claims.VerifyIssuer(Issuer(), true) {
+		return fmt.Errorf("invalid issuer")
+	}
+	if !claims.VerifyAudience(Audience(), true) {
+		return fmt.Errorf("invalid audience")
+	}
+	if r, ok := claims["role"].(string); !ok || models.Role(r) != role {
+		return fmt.Errorf("token was not issued for role %s", role)
+	}
+	if jti, ok := claims["jti"].(string); !ok || jti == "" {
+		return fmt.Errorf("missing jti")
+	}
+	if _, err := GetSubject(claims); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetSubject returns the account ID held in the sub claim
+func GetSubject(claims jwt.MapClaims) (uint, error) {
+	sub, ok := claims["sub"].(string)
+	if !ok {
+		return 0, fmt.Errorf("missing subject")
+	}
+	id, err := strconv.ParseUint(sub, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid subject: %w", err)
+	}
+	return uint(id), nil
+}
+
 func RespondAndAbort(c *gin.Context, message string, status int, data interface{}, errs []string) {
 	util.Response(c, message, status, data, errs)
 	c.Abort()
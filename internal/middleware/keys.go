@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningKey is one entry of the key set. Retired keys only carry a public key
+// and are kept so tokens they signed stay valid until they expire.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// KeySet signs tokens with its active key and verifies them with any key it holds,
+// selected by the kid header
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+	// secret is the legacy HS256 JWT_SECRET, used for tokens without a kid
+	secret []byte
+}
+
+// JWK is the public half of a key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served on /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewKeySet builds a key set. activeID must name a key holding a private key, or be
+// empty to sign with the HS256 secret. secret may be empty once no HS256 tokens remain.
+func NewKeySet(keys []*SigningKey, activeID string, secret string) (*KeySet, error) {
+	keySet := &KeySet{
+		keys:   make(map[string]*SigningKey),
+		secret: []byte(secret),
+	}
+
+	for _, key := range keys {
+		if _, exists := keySet.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		keySet.keys[key.ID] = key
+	}
+
+	if activeID != "" {
+		active, ok := keySet.keys[activeID]
+		if !ok {
+			return nil, fmt.Errorf("active key %q not found", activeID)
+		}
+		if active.Private == nil {
+			return nil, fmt.Errorf("active key %q has no private key", activeID)
+		}
+		keySet.active = active
+	} else if len(keySet.secret) == 0 {
+		return nil, fmt.Errorf("no signing key configured: set JWT_ACTIVE_KID or JWT_SECRET")
+	}
+
+	return keySet, nil
+}
+
+// LoadKeySet builds the key set from the environment. JWT_KEYS_DIR holds one PEM file
+// per key, named <kid>.pem, containing either a private key (RSA or Ed25519) or the
+// public key of a retired key. JWT_ACTIVE_KID selects the key new tokens are signed with.
+func LoadKeySet() (*KeySet, error) {
+	var keys []*SigningKey
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			key, err := ParseSigningKey(strings.TrimSuffix(filepath.Base(file), ".pem"), data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	return NewKeySet(keys, os.Getenv("JWT_ACTIVE_KID"), os.Getenv("JWT_SECRET"))
+}
+
+// ParseSigningKey parses a PEM encoded RSA or Ed25519 private or public key
+func ParseSigningKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: id}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	if public, ok := key.Public.(*rsa.PublicKey); ok && public.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("RSA keys must be at least 2048 bits")
+	}
+
+	return key, nil
+}
+
+// Sign signs the claims with the active key, or with the HS256 secret if there is none
+func (k *KeySet) Sign(claims jwt.MapClaims) (*string, error) {
+	var token *jwt.Token
+	var key interface{}
+	if k.active != nil {
+		token = jwt.NewWithClaims(k.active.Method, claims)
+		token.Header["kid"] = k.active.ID
+		key = k.active.Private
+	} else {
+		token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		key = k.secret
+	}
+
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenString, nil
+}
+
+// keyFunc picks the verification key named by the kid header and refuses any
+// algorithm other than the one that key was loaded for
+func (k *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		if _, isHMAC := token.Method.(*jwt.SigningMethodHMAC); !isHMAC || len(k.secret) == 0 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return k.secret, nil
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// JWKS returns the public keys other services need to verify our tokens
+func (k *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range k.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Account is the identity behind a login: one email, one password and one second factor.
+// What the account may do comes from its role memberships, each of which is a profile row
+// pointing back at it: a User (buyer), a Seller or an Admin.
+type Account struct {
+	gorm.Model
+	Email       string `json:"email" gorm:"uniqueIndex;not null"`
+	Password    string `json:"-" gorm:"not null"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	DateOfBirth string `json:"date_of_birth"`
+	Phone       string `json:"phone"`
+	Address     string `json:"address"`
+	// EmailVerifiedAt is nil until the account confirms its email address
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	TwoFactor       `gorm:"embedded"`
+	// TokenVersion is stamped on every token; bumping it signs the account out everywhere
+	TokenVersion uint `json:"-" gorm:"not null;default:0"`
+}
+
+// SignupRequest holds the identity fields every new account needs
+type SignupRequest struct {
+	FirstName   string `json:"first_name" binding:"required"`
+	LastName    string `json:"last_name" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	DateOfBirth string `json:"date_of_birth"`
+	Email       string `json:"email" binding:"required,email"`
+	Phone       string `json:"phone"`
+	Address     string `json:"address"`
+}
+
+// NewAccount builds an account from a signup; the password must already be hashed
+func (r *SignupRequest) NewAccount(hashedPassword string) *Account {
+	return &Account{
+		Email:       r.Email,
+		Password:    hashedPassword,
+		FirstName:   r.FirstName,
+		LastName:    r.LastName,
+		DateOfBirth: r.DateOfBirth,
+		Phone:       r.Phone,
+		Address:     r.Address,
+	}
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// DeleteAccountRequest asks for the password again so a stolen token cannot close the account
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// UpdateProfileRequest is a partial update: only the fields present in the body change
+type UpdateProfileRequest struct {
+	FirstName   *string `json:"first_name"`
+	LastName    *string `json:"last_name"`
+	DateOfBirth *string `json:"date_of_birth"`
+	Phone       *string `json:"phone"`
+	Address     *string `json:"address"`
+}
+
+// Apply copies the fields present in the request onto the account
+func (r *UpdateProfileRequest) Apply(account *Account) {
+	if r.FirstName != nil {
+		account.FirstName = *r.FirstName
+	}
+	if r.LastName != nil {
+		account.LastName = *r.LastName
+	}
+	if r.DateOfBirth != nil {
+		account.DateOfBirth = *r.DateOfBirth
+	}
+	if r.Phone != nil {
+		account.Phone = *r.Phone
+	}
+	if r.Address != nil {
+		account.Address = *r.Address
+	}
+}
+
+// UpdateSellerProfileRequest adds the store fields to a profile update
+type UpdateSellerProfileRequest struct {
+	UpdateProfileRequest
+	StoreName     *string `json:"store_name"`
+	StoreCategory *string `json:"store_category"`
+}
+
+// Apply copies the fields present in the request onto the seller and its account
+func (r *UpdateSellerProfileRequest) Apply(seller *Seller) {
+	r.UpdateProfileRequest.Apply(seller.Account)
+	if r.StoreName != nil {
+		seller.StoreName = *r.StoreName
+	}
+	if r.StoreCategory != nil {
+		seller.StoreCategory = *r.StoreCategory
+	}
+}
+
+// UserDataExport is everything stored about a user
+type UserDataExport struct {
+	ExportedAt time.Time              `json:"exported_at"`
+	Profile    *UserResponse          `json:"profile"`
+	Addresses  []*AddressResponse     `json:"addresses"`
+	Cart       []*CartEntryResponse   `json:"cart"`
+	Wishlist   *WishlistResponse      `json:"wishlist"`
+	Reviews    []*ReviewResponse      `json:"reviews"`
+	History    []*HistoryItemResponse `json:"history"`
+	Orders     []*OrderResponse       `json:"orders"`
+	Sessions   []*SessionResponse     `json:"sessions"`
+}
+
+// SellerDataExport is everything stored about a seller
+type SellerDataExport struct {
+	ExportedAt time.Time          `json:"exported_at"`
+	Profile    *SellerResponse    `json:"profile"`
+	Products   []*ProductResponse `json:"products"`
+	Orders     []*OrderResponse   `json:"orders"`
+	Sessions   []*SessionResponse `json:"sessions"`
+	APIKeys    []*APIKeyResponse  `json:"api_keys"`
+}
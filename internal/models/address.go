@@ -0,0 +1,102 @@
+package models
+
+import "gorm.io/gorm"
+
+// PostalAddress is where a parcel goes. Orders keep their own copy, so editing or
+// deleting an address book entry never changes where a placed order ships.
+type PostalAddress struct {
+	RecipientName string `json:"recipient_name"`
+	Phone         string `json:"phone"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2"`
+	City          string `json:"city"`
+	State         string `json:"state"`
+	PostalCode    string `json:"postal_code"`
+	Country       string `json:"country"`
+}
+
+// Address is one entry in a user's address book. At most one per user is the default.
+type Address struct {
+	gorm.Model
+	UserID        uint   `json:"user_id" gorm:"index;not null"`
+	Label         string `json:"label"`
+	PostalAddress `gorm:"embedded"`
+	IsDefault     bool `json:"is_default"`
+}
+
+type CreateAddressRequest struct {
+	Label         string `json:"label"`
+	RecipientName string `json:"recipient_name" binding:"required"`
+	Phone         string `json:"phone"`
+	Line1         string `json:"line1" binding:"required"`
+	Line2         string `json:"line2"`
+	City          string `json:"city" binding:"required"`
+	State         string `json:"state"`
+	PostalCode    string `json:"postal_code" binding:"required"`
+	Country       string `json:"country" binding:"required"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+// NewAddress builds the user's address book entry from the request
+func (r *CreateAddressRequest) NewAddress(userID uint) *Address {
+	return &Address{
+		UserID: userID,
+		Label:  r.Label,
+		PostalAddress: PostalAddress{
+			RecipientName: r.RecipientName,
+			Phone:         r.Phone,
+			Line1:         r.Line1,
+			Line2:         r.Line2,
+			City:          r.City,
+			State:         r.State,
+			PostalCode:    r.PostalCode,
+			Country:       r.Country,
+		},
+		IsDefault: r.IsDefault,
+	}
+}
+
+// UpdateAddressRequest is a partial update: only the fields present in the body change
+type UpdateAddressRequest struct {
+	Label         *string `json:"label"`
+	RecipientName *string `json:"recipient_name" binding:"omitempty,min=1"`
+	Phone         *string `json:"phone"`
+	Line1         *string `json:"line1" binding:"omitempty,min=1"`
+	Line2         *string `json:"line2"`
+	City          *string `json:"city" binding:"omitempty,min=1"`
+	State         *string `json:"state"`
+	PostalCode    *string `json:"postal_code" binding:"omitempty,min=1"`
+	Country       *string `json:"country" binding:"omitempty,min=1"`
+	IsDefault     *bool   `json:"is_default"`
+}
+
+// Apply copies the fields present in the request onto the address
+func (r *UpdateAddressRequest) Apply(address *Address) {
+	fields := []struct {
+		value  *string
+		target *string
+	}{
+		{r.Label, &address.Label},
+		{r.RecipientName, &address.RecipientName},
+		{r.Phone, &address.Phone},
+		{r.Line1, &address.Line1},
+		{r.Line2, &address.Line2},
+		{r.City, &address.City},
+		{r.State, &address.State},
+		{r.PostalCode, &address.PostalCode},
+		{r.Country, &address.Country},
+	}
+	for _, field := range fields {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+	if r.IsDefault != nil {
+		address.IsDefault = *r.IsDefault
+	}
+}
+
+// PlaceOrderRequest names the address book entry the order ships to
+type PlaceOrderRequest struct {
+	AddressID uint `json:"address_id" binding:"required"`
+}
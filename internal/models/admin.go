@@ -0,0 +1,27 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Admin is the admin membership of an Account. Admins cannot sign up; the first one
+// is created from ADMIN_EMAIL and ADMIN_PASSWORD at startup and can add the others.
+type Admin struct {
+	gorm.Model
+	AccountID uint     `json:"account_id" gorm:"uniqueIndex"`
+	Account   *Account `json:"account"`
+}
+
+type LoginRequestAdmin struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// CreateAdminRequest creates a new account with admin rights, or grants them to an
+// existing account with this email, in which case the other fields are ignored
+type CreateAdminRequest struct {
+	FirstName string `json:"first_name" binding:"required"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required"`
+}
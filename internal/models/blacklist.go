@@ -1,10 +1,15 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// BlacklistTokens records a revoked access token by its jti. Rows can be purged
+// once ExpiresAt has passed since the token would be rejected as expired anyway.
 type BlacklistTokens struct {
 	gorm.Model
-	Token string `json:"token" gorm:"not null"`
+	TokenID   string    `json:"token_id" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
 }
@@ -10,10 +10,16 @@ type IndividualItemInCart struct {
 	OrderID   *uint `json:"order_id" gorm:"default:null"`
 }
 
+// CartItemRequest adds a product to the cart or changes its quantity
+type CartItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Quantity  int  `json:"quantity" binding:"required,gt=0"`
+}
+
 type CartItem struct {
-	CartID   uint     `json:"cart_id"`
-	Product  *Product `json:"product"`
-	Quantity int      `json:"quantity"`
+	CartID   uint             `json:"cart_id"`
+	Product  *ProductResponse `json:"product"`
+	Quantity int              `json:"quantity"`
 }
 
 type CartTotal struct {
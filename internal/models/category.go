@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// ErrCategorySlugTaken is returned when another category already uses the slug
+var ErrCategorySlugTaken = errors.New("category slug already in use")
+
+// Category is a node in the product category tree. Root categories have no parent.
+type Category struct {
+	gorm.Model
+	Name     string `json:"name" gorm:"not null"`
+	Slug     string `json:"slug" gorm:"uniqueIndex;not null"`
+	ParentID *uint  `json:"parent_id" gorm:"index"`
+}
+
+type CreateCategoryRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// Slug defaults to one derived from the name
+	Slug     string `json:"slug" binding:"omitempty,max=100"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+// UpdateCategoryRequest is a partial update. A parent_id of 0 moves the category to the root.
+type UpdateCategoryRequest struct {
+	Name     *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Slug     *string `json:"slug" binding:"omitempty,min=1,max=100"`
+	ParentID *uint   `json:"parent_id"`
+}
+
+// Slugify lowercases text and joins its words with dashes, e.g. "Home & Garden" becomes "home-garden"
+func Slugify(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// IsDescendant reports whether candidate is categoryID itself or lies below it in the tree
+func IsDescendant(categories []Category, categoryID, candidate uint) bool {
+	parents := make(map[uint]*uint, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+	for seen := 0; seen <= len(categories); seen++ {
+		if candidate == categoryID {
+			return true
+		}
+		parent := parents[candidate]
+		if parent == nil {
+			return false
+		}
+		candidate = *parent
+	}
+	return false
+}
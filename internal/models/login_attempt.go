@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginThrottle counts recent failed logins for one key: an account email or a client IP.
+// Rows are deleted once the key logs in successfully, or by a periodic sweep once its
+// failures age out and any lockout has ended.
+type LoginThrottle struct {
+	ID            uint       `json:"id" gorm:"primarykey"`
+	Key           string     `json:"key" gorm:"uniqueIndex;not null"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+// AccountLockout is the audit record of a temporary lockout, kept for admins
+type AccountLockout struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"created_at"`
+	Key         string    `json:"key" gorm:"index;not null"`
+	Role        Role      `json:"role"`
+	Email       string    `json:"email"`
+	IPAddress   string    `json:"ip_address"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+}
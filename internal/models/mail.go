@@ -0,0 +1,8 @@
+package models
+
+// MailMessage is a plain-text email handed to a Mailer
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationType string
+
+const (
+	NotificationOrderPlaced    NotificationType = "ORDER_PLACED"
+	NotificationOrderAccepted  NotificationType = "ORDER_ACCEPTED"
+	NotificationOrderDeclined  NotificationType = "ORDER_DECLINED"
+	NotificationOrderCompleted NotificationType = "ORDER_COMPLETED"
+	NotificationLowStock       NotificationType = "LOW_STOCK"
+)
+
+// Notification is an in-app message for one membership: a buyer (RoleUser and a user ID)
+// or a seller (RoleSeller and a seller ID)
+type Notification struct {
+	gorm.Model
+	Role        Role             `json:"role" gorm:"index:idx_notification_recipient;not null"`
+	RecipientID uint             `json:"recipient_id" gorm:"index:idx_notification_recipient;not null"`
+	Type        NotificationType `json:"type" gorm:"not null"`
+	Title       string           `json:"title"`
+	Message     string           `json:"message"`
+	OrderID     *uint            `json:"order_id"`
+	ProductID   *uint            `json:"product_id"`
+	ReadAt      *time.Time       `json:"read_at"`
+}
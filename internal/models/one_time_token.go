@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TokenPurpose says what a OneTimeToken may be redeemed for
+type TokenPurpose string
+
+const (
+	PurposePasswordReset     TokenPurpose = "password_reset"
+	PurposeEmailVerification TokenPurpose = "email_verification"
+)
+
+// OneTimeToken is a single-use, time-limited secret sent to an account holder.
+// Only the SHA-256 hash of the token is stored.
+type OneTimeToken struct {
+	gorm.Model
+	Purpose   TokenPurpose `json:"purpose" gorm:"index;not null"`
+	Role      Role         `json:"role" gorm:"not null"`
+	AccountID uint         `json:"account_id" gorm:"not null"`
+	TokenHash string       `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
@@ -1,6 +1,13 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrInsufficientStock is returned when an order asks for more units than are left
+var ErrInsufficientStock = errors.New("insufficient stock")
 
 type Order struct {
 	gorm.Model
@@ -8,6 +15,8 @@ type Order struct {
 	Items  []*OrderItem `json:"items"`
 	Total  float64      `json:"total"`
 	Status OrderStatus  `json:"status"`
+	// ShippingAddress is a snapshot of the address book entry chosen at checkout
+	ShippingAddress PostalAddress `json:"shipping_address" gorm:"embedded;embeddedPrefix:shipping_"`
 }
 
 type OrderItem struct {
@@ -0,0 +1,10 @@
+package models
+
+import "gorm.io/gorm"
+
+// PasswordHistory keeps the hashes of an account's recent passwords so they cannot be reused
+type PasswordHistory struct {
+	gorm.Model
+	AccountID    uint   `json:"account_id" gorm:"index;not null"`
+	PasswordHash string `json:"-" gorm:"not null"`
+}
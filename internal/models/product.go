@@ -6,7 +6,9 @@ import (
 
 type Product struct {
 	gorm.Model
-	SellerID    uint    `json:"seller_id"`
+	SellerID uint `json:"seller_id"`
+	// CategoryID is required for new products; products created before categories existed have none
+	CategoryID  *uint   `json:"category_id" gorm:"index"`
 	Title       string  `json:"title"`
 	ImageUrl    string  `json:"image_url"`
 	Price       float64 `json:"price"`
@@ -15,4 +17,75 @@ type Product struct {
 	Description string  `json:"description"`
 	Status      bool    `json:"status"`
 	Orders      []Order `json:"orders" gorm:"many2many:order_items;"`
+	// RatingAverage and RatingCount summarise the product's reviews
+	RatingAverage float64 `json:"rating_average" gorm:"not null;default:0"`
+	RatingCount   int     `json:"rating_count" gorm:"not null;default:0"`
+}
+
+// CreateProductRequest is what a seller may set on a new product
+type CreateProductRequest struct {
+	CategoryID  uint    `json:"category_id" binding:"required"`
+	Title       string  `json:"title" binding:"required"`
+	ImageUrl    string  `json:"image_url" binding:"omitempty,url"`
+	Price       float64 `json:"price" binding:"gte=0"`
+	Quantity    int     `json:"quantity" binding:"gte=0"`
+	Overview    string  `json:"overview"`
+	Description string  `json:"description"`
+	Status      bool    `json:"status"`
+}
+
+// UpdateProductRequest is a partial update: only the fields present in the body change
+type UpdateProductRequest struct {
+	CategoryID  *uint    `json:"category_id" binding:"omitempty,min=1"`
+	Title       *string  `json:"title" binding:"omitempty,min=1"`
+	ImageUrl    *string  `json:"image_url" binding:"omitempty,url"`
+	Price       *float64 `json:"price" binding:"omitempty,gte=0"`
+	Quantity    *int     `json:"quantity" binding:"omitempty,gte=0"`
+	Overview    *string  `json:"overview"`
+	Description *string  `json:"description"`
+}
+
+// Apply copies the fields present in the request onto the product
+func (r *UpdateProductRequest) Apply(product *Product) {
+	if r.CategoryID != nil {
+		product.CategoryID = r.CategoryID
+	}
+	if r.Title != nil {
+		product.Title = *r.Title
+	}
+	if r.ImageUrl != nil {
+		product.ImageUrl = *r.ImageUrl
+	}
+	if r.Price != nil {
+		product.Price = *r.Price
+	}
+	if r.Quantity != nil {
+		product.Quantity = *r.Quantity
+	}
+	if r.Overview != nil {
+		product.Overview = *r.Overview
+	}
+	if r.Description != nil {
+		product.Description = *r.Description
+	}
+}
+
+// ProductStatusRequest puts a product on or off sale
+type ProductStatusRequest struct {
+	Status *bool `json:"status" binding:"required"`
+}
+
+// NewProduct builds the seller's product from the request
+func (r *CreateProductRequest) NewProduct(sellerID uint) *Product {
+	return &Product{
+		SellerID:    sellerID,
+		CategoryID:  &r.CategoryID,
+		Title:       r.Title,
+		ImageUrl:    r.ImageUrl,
+		Price:       r.Price,
+		Quantity:    r.Quantity,
+		Overview:    r.Overview,
+		Description: r.Description,
+		Status:      r.Status,
+	}
 }
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// DefaultProductPageSize is the page size when the client does not ask for one
+const DefaultProductPageSize = 20
+
+// ProductSort is how a product listing is ordered
+type ProductSort string
+
+const (
+	ProductSortNewest    ProductSort = "newest"
+	ProductSortPrice     ProductSort = "price"
+	ProductSortPriceDesc ProductSort = "price_desc"
+	ProductSortTitle     ProductSort = "title"
+	// ProductSortRelevance orders search results by rank; the plain catalogue treats it as newest
+	ProductSortRelevance ProductSort = "relevance"
+)
+
+// ProductQuery filters, sorts and pages the catalogue. It binds from the query string.
+// The catalogue only ever lists products on sale; sellers see the rest in their own listing.
+type ProductQuery struct {
+	MinPrice *float64 `form:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice *float64 `form:"max_price" binding:"omitempty,gte=0"`
+	SellerID uint     `form:"seller_id"`
+	// CategoryID matches products in the category and all of its descendants
+	CategoryID uint `form:"category_id"`
+	InStock    bool `form:"in_stock"`
+	// CreatedAfter is an RFC 3339 timestamp
+	CreatedAfter *time.Time  `form:"created_after"`
+	Sort         ProductSort `form:"sort" binding:"omitempty,oneof=newest price price_desc title relevance"`
+	Page         int         `form:"page" binding:"omitempty,min=1"`
+	Limit        int         `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// Normalize fills in the defaults for anything the client left out
+func (q *ProductQuery) Normalize() {
+	if q.Sort == "" {
+		q.Sort = ProductSortNewest
+	}
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultProductPageSize
+	}
+}
+
+func (q *ProductQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// ProductSearchResult is a product matching a search, with its rank and the matched
+// text highlighted
+type ProductSearchResult struct {
+	Product
+	Rank           float64
+	TitleHighlight string
+	Snippet        string
+}
+
+// PageMeta describes one page of a listing
+type PageMeta struct {
+	Total int64 `json:"total"`
+	Page  int   `json:"page"`
+	Limit int   `json:"limit"`
+	Pages int64 `json:"pages"`
+}
+
+func NewPageMeta(total int64, page, limit int) *PageMeta {
+	return &PageMeta{
+		Total: total,
+		Page:  page,
+		Limit: limit,
+		Pages: (total + int64(limit) - 1) / int64(limit),
+	}
+}
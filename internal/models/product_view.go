@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ProductView records that a user looked at a product. There is one row per user and
+// product: viewing it again moves ViewedAt forward and bumps Views.
+type ProductView struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_product_view_user_product;not null"`
+	ProductID uint      `json:"product_id" gorm:"uniqueIndex:idx_product_view_user_product;not null"`
+	Product   *Product  `json:"product" gorm:"foreignKey:ProductID"`
+	Views     int       `json:"views" gorm:"not null;default:1"`
+	ViewedAt  time.Time `json:"viewed_at" gorm:"index;not null"`
+}
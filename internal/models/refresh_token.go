@@ -0,0 +1,29 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token that was already rotated is presented again
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+// RefreshToken tracks every refresh token issued so it can be rotated exactly once.
+// Tokens minted from the same login share a FamilyID, which lets a replayed token
+// revoke the whole chain.
+type RefreshToken struct {
+	gorm.Model
+	TokenID   string     `json:"token_id" gorm:"uniqueIndex;not null"`
+	FamilyID  string     `json:"family_id" gorm:"index;not null"`
+	Role      Role       `json:"role" gorm:"not null"`
+	AccountID uint       `json:"account_id" gorm:"not null"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -0,0 +1,550 @@
+package models
+
+import "time"
+
+// Response types are what the API serializes. Handlers never return the gorm models
+// directly, so password hashes, TOTP secrets and other internal fields cannot leak
+// when a model grows a new column.
+
+// AccountResponse is the public view of an account's identity
+type AccountResponse struct {
+	ID               uint       `json:"id"`
+	Email            string     `json:"email"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	DateOfBirth      string     `json:"date_of_birth"`
+	Phone            string     `json:"phone"`
+	Address          string     `json:"address"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func NewAccountResponse(account *Account) *AccountResponse {
+	if account == nil {
+		return nil
+	}
+	return &AccountResponse{
+		ID:               account.ID,
+		Email:            account.Email,
+		FirstName:        account.FirstName,
+		LastName:         account.LastName,
+		DateOfBirth:      account.DateOfBirth,
+		Phone:            account.Phone,
+		Address:          account.Address,
+		EmailVerifiedAt:  account.EmailVerifiedAt,
+		TwoFactorEnabled: account.TOTPEnabled,
+		CreatedAt:        account.CreatedAt,
+	}
+}
+
+type UserResponse struct {
+	ID        uint             `json:"id"`
+	CreatedAt time.Time        `json:"created_at"`
+	Account   *AccountResponse `json:"account"`
+}
+
+func NewUserResponse(user *User) *UserResponse {
+	return &UserResponse{
+		ID:        user.ID,
+		CreatedAt: user.CreatedAt,
+		Account:   NewAccountResponse(user.Account),
+	}
+}
+
+type SellerResponse struct {
+	ID            uint             `json:"id"`
+	StoreName     string           `json:"store_name"`
+	StoreCategory string           `json:"store_category"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Account       *AccountResponse `json:"account"`
+}
+
+func NewSellerResponse(seller *Seller) *SellerResponse {
+	return &SellerResponse{
+		ID:            seller.ID,
+		StoreName:     seller.StoreName,
+		StoreCategory: seller.StoreCategory,
+		CreatedAt:     seller.CreatedAt,
+		Account:       NewAccountResponse(seller.Account),
+	}
+}
+
+type AdminResponse struct {
+	ID        uint             `json:"id"`
+	CreatedAt time.Time        `json:"created_at"`
+	Account   *AccountResponse `json:"account"`
+}
+
+func NewAdminResponse(admin *Admin) *AdminResponse {
+	return &AdminResponse{
+		ID:        admin.ID,
+		CreatedAt: admin.CreatedAt,
+		Account:   NewAccountResponse(admin.Account),
+	}
+}
+
+type ProductResponse struct {
+	ID            uint      `json:"id"`
+	SellerID      uint      `json:"seller_id"`
+	CategoryID    *uint     `json:"category_id"`
+	Title         string    `json:"title"`
+	ImageUrl      string    `json:"image_url"`
+	Price         float64   `json:"price"`
+	Quantity      int       `json:"quantity"`
+	Overview      string    `json:"overview"`
+	Description   string    `json:"description"`
+	Status        bool      `json:"status"`
+	RatingAverage float64   `json:"rating_average"`
+	RatingCount   int       `json:"rating_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func NewProductResponse(product *Product) *ProductResponse {
+	if product == nil {
+		return nil
+	}
+	return &ProductResponse{
+		ID:            product.ID,
+		SellerID:      product.SellerID,
+		CategoryID:    product.CategoryID,
+		Title:         product.Title,
+		ImageUrl:      product.ImageUrl,
+		Price:         product.Price,
+		Quantity:      product.Quantity,
+		Overview:      product.Overview,
+		Description:   product.Description,
+		Status:        product.Status,
+		RatingAverage: product.RatingAverage,
+		RatingCount:   product.RatingCount,
+		CreatedAt:     product.CreatedAt,
+		UpdatedAt:     product.UpdatedAt,
+	}
+}
+
+func NewProductResponses(products []Product) []*ProductResponse {
+	result := make([]*ProductResponse, 0, len(products))
+	for i := range products {
+		result = append(result, NewProductResponse(&products[i]))
+	}
+	return result
+}
+
+type OrderItemResponse struct {
+	ProductID uint             `json:"product_id"`
+	Quantity  int              `json:"quantity"`
+	Product   *ProductResponse `json:"product,omitempty"`
+}
+
+type OrderResponse struct {
+	ID     uint                 `json:"id"`
+	UserID uint                 `json:"user_id"`
+	Items  []*OrderItemResponse `json:"items"`
+	Total  float64              `json:"total"`
+	Status OrderStatus          `json:"status"`
+	// ShippingAddress is where the order ships, as it was when the order was placed
+	ShippingAddress PostalAddress `json:"shipping_address"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+func NewOrderResponse(order *Order) *OrderResponse {
+	items := make([]*OrderItemResponse, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, &OrderItemResponse{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Product:   NewProductResponse(item.Product),
+		})
+	}
+	return &OrderResponse{
+		ID:              order.ID,
+		UserID:          order.UserID,
+		Items:           items,
+		Total:           order.Total,
+		Status:          order.Status,
+		ShippingAddress: order.ShippingAddress,
+		CreatedAt:       order.CreatedAt,
+		UpdatedAt:       order.UpdatedAt,
+	}
+}
+
+func NewOrderResponses(orders []*Order) []*OrderResponse {
+	result := make([]*OrderResponse, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, NewOrderResponse(order))
+	}
+	return result
+}
+
+// CartEntryResponse is a raw cart row, without the product details
+type CartEntryResponse struct {
+	ID        uint      `json:"id"`
+	ProductID uint      `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewCartEntryResponses(entries []*IndividualItemInCart) []*CartEntryResponse {
+	result := make([]*CartEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, &CartEntryResponse{
+			ID:        entry.ID,
+			ProductID: entry.ProductID,
+			Quantity:  entry.Quantity,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return result
+}
+
+type AddressResponse struct {
+	ID        uint          `json:"id"`
+	Label     string        `json:"label"`
+	Address   PostalAddress `json:"address"`
+	IsDefault bool          `json:"is_default"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+func NewAddressResponse(address *Address) *AddressResponse {
+	return &AddressResponse{
+		ID:        address.ID,
+		Label:     address.Label,
+		Address:   address.PostalAddress,
+		IsDefault: address.IsDefault,
+		CreatedAt: address.CreatedAt,
+		UpdatedAt: address.UpdatedAt,
+	}
+}
+
+func NewAddressResponses(addresses []Address) []*AddressResponse {
+	result := make([]*AddressResponse, 0, len(addresses))
+	for i := range addresses {
+		result = append(result, NewAddressResponse(&addresses[i]))
+	}
+	return result
+}
+
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	Role       Role      `json:"role"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session making the request
+	Current bool `json:"current"`
+}
+
+// NewSessionResponses flags the session whose family matches currentFamilyID
+func NewSessionResponses(sessions []Session, currentFamilyID string) []*SessionResponse {
+	result := make([]*SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, &SessionResponse{
+			ID:         session.ID,
+			Role:       session.Role,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    currentFamilyID != "" && session.FamilyID == currentFamilyID,
+		})
+	}
+	return result
+}
+
+// APIKeyResponse shows a key without its hash. Key holds the plaintext only in the
+// response to the request that created it.
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Key        string     `json:"key,omitempty"`
+}
+
+func NewAPIKeyResponse(key *APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		ExpiresAt:  key.ExpiresAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+func NewAPIKeyResponses(keys []APIKey) []*APIKeyResponse {
+	result := make([]*APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		result = append(result, NewAPIKeyResponse(&keys[i]))
+	}
+	return result
+}
+
+// AccountLockoutResponse is the admin view of a lockout; the throttle key stays internal
+type AccountLockoutResponse struct {
+	ID          uint      `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Role        Role      `json:"role"`
+	Email       string    `json:"email"`
+	IPAddress   string    `json:"ip_address"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func NewAccountLockoutResponses(lockouts []AccountLockout) []*AccountLockoutResponse {
+	result := make([]*AccountLockoutResponse, 0, len(lockouts))
+	for _, lockout := range lockouts {
+		result = append(result, &AccountLockoutResponse{
+			ID:          lockout.ID,
+			CreatedAt:   lockout.CreatedAt,
+			Role:        lockout.Role,
+			Email:       lockout.Email,
+			IPAddress:   lockout.IPAddress,
+			Failures:    lockout.Failures,
+			LockedUntil: lockout.LockedUntil,
+		})
+	}
+	return result
+}
+
+// WishlistItemResponse shows a saved product with its current price and stock
+type WishlistItemResponse struct {
+	ProductID uint    `json:"product_id"`
+	Title     string  `json:"title"`
+	ImageUrl  string  `json:"image_url"`
+	Price     float64 `json:"price"`
+	Stock     int     `json:"stock"`
+	// Available is false when the product is off sale or out of stock
+	Available bool      `json:"available"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+type WishlistResponse struct {
+	Items  []*WishlistItemResponse `json:"items"`
+	Shared bool                    `json:"shared"`
+}
+
+// NewWishlistResponse skips saved products that have since been deleted
+func NewWishlistResponse(wishlist *Wishlist) *WishlistResponse {
+	items := make([]*WishlistItemResponse, 0, len(wishlist.Items))
+	for _, item := range wishlist.Items {
+		if item.Product == nil {
+			continue
+		}
+		items = append(items, &WishlistItemResponse{
+			ProductID: item.ProductID,
+			Title:     item.Product.Title,
+			ImageUrl:  item.Product.ImageUrl,
+			Price:     item.Product.Price,
+			Stock:     item.Product.Quantity,
+			Available: item.Product.Status && item.Product.Quantity > 0,
+			AddedAt:   item.CreatedAt,
+		})
+	}
+	return &WishlistResponse{
+		Items:  items,
+		Shared: wishlist.ShareTokenHash != nil,
+	}
+}
+
+type NotificationResponse struct {
+	ID        uint             `json:"id"`
+	Type      NotificationType `json:"type"`
+	Title     string           `json:"title"`
+	Message   string           `json:"message"`
+	OrderID   *uint            `json:"order_id,omitempty"`
+	ProductID *uint            `json:"product_id,omitempty"`
+	Read      bool             `json:"read"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+func NewNotificationResponses(notifications []Notification) []*NotificationResponse {
+	result := make([]*NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		result = append(result, &NotificationResponse{
+			ID:        notification.ID,
+			Type:      notification.Type,
+			Title:     notification.Title,
+			Message:   notification.Message,
+			OrderID:   notification.OrderID,
+			ProductID: notification.ProductID,
+			Read:      notification.ReadAt != nil,
+			ReadAt:    notification.ReadAt,
+			CreatedAt: notification.CreatedAt,
+		})
+	}
+	return result
+}
+
+type ReviewResponse struct {
+	ID        uint   `json:"id"`
+	ProductID uint   `json:"product_id"`
+	Reviewer  string `json:"reviewer"`
+	Rating    int    `json:"rating"`
+	Body      string `json:"body"`
+	// SellerResponse is omitted until the seller answers
+	SellerResponse *ReviewReplyResponse `json:"seller_response,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+type ReviewReplyResponse struct {
+	Body        string    `json:"body"`
+	RespondedAt time.Time `json:"responded_at"`
+}
+
+// NewReviewResponse names the reviewer by first name only
+func NewReviewResponse(review *Review) *ReviewResponse {
+	response := &ReviewResponse{
+		ID:        review.ID,
+		ProductID: review.ProductID,
+		Rating:    review.Rating,
+		Body:      review.Body,
+		CreatedAt: review.CreatedAt,
+	}
+	if review.User != nil && review.User.Account != nil {
+		response.Reviewer = review.User.Account.FirstName
+	}
+	if review.RespondedAt != nil {
+		response.SellerResponse = &ReviewReplyResponse{
+			Body:        review.SellerResponse,
+			RespondedAt: *review.RespondedAt,
+		}
+	}
+	return response
+}
+
+func NewReviewResponses(reviews []Review) []*ReviewResponse {
+	result := make([]*ReviewResponse, 0, len(reviews))
+	for i := range reviews {
+		result = append(result, NewReviewResponse(&reviews[i]))
+	}
+	return result
+}
+
+// HistoryItemResponse shows a viewed product with its current price and stock
+type HistoryItemResponse struct {
+	ProductID uint      `json:"product_id"`
+	Title     string    `json:"title"`
+	ImageUrl  string    `json:"image_url"`
+	Price     float64   `json:"price"`
+	Stock     int       `json:"stock"`
+	Available bool      `json:"available"`
+	Views     int       `json:"views"`
+	ViewedAt  time.Time `json:"viewed_at"`
+}
+
+// NewHistoryResponses skips viewed products that have since been deleted
+func NewHistoryResponses(views []ProductView) []*HistoryItemResponse {
+	result := make([]*HistoryItemResponse, 0, len(views))
+	for _, view := range views {
+		if view.Product == nil {
+			continue
+		}
+		result = append(result, &HistoryItemResponse{
+			ProductID: view.ProductID,
+			Title:     view.Product.Title,
+			ImageUrl:  view.Product.ImageUrl,
+			Price:     view.Product.Price,
+			Stock:     view.Product.Quantity,
+			Available: view.Product.Status && view.Product.Quantity > 0,
+			Views:     view.Views,
+			ViewedAt:  view.ViewedAt,
+		})
+	}
+	return result
+}
+
+// ProductSearchResponse is a search hit. TitleHighlight and Snippet are HTML: the
+// product's own text, escaped, with matched words wrapped in <mark> tags.
+type ProductSearchResponse struct {
+	Product        *ProductResponse `json:"product"`
+	Rank           float64          `json:"rank"`
+	TitleHighlight string           `json:"title_highlight"`
+	Snippet        string           `json:"snippet"`
+}
+
+func NewProductSearchResponses(results []ProductSearchResult) []*ProductSearchResponse {
+	result := make([]*ProductSearchResponse, 0, len(results))
+	for i := range results {
+		result = append(result, &ProductSearchResponse{
+			Product:        NewProductResponse(&results[i].Product),
+			Rank:           results[i].Rank,
+			TitleHighlight: results[i].TitleHighlight,
+			Snippet:        results[i].Snippet,
+		})
+	}
+	return result
+}
+
+// CategoryResponse is a category with its subtree. ProductCount includes the products of
+// every descendant.
+type CategoryResponse struct {
+	ID           uint                `json:"id"`
+	Name         string              `json:"name"`
+	Slug         string              `json:"slug"`
+	ParentID     *uint               `json:"parent_id"`
+	ProductCount int64               `json:"product_count"`
+	Children     []*CategoryResponse `json:"children"`
+}
+
+// NewCategoryTree arranges categories into trees and rolls each category's direct
+// product count up to its ancestors
+func NewCategoryTree(categories []Category, counts map[uint]int64) []*CategoryResponse {
+	nodes := make(map[uint]*CategoryResponse, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryResponse{
+			ID:       category.ID,
+			Name:     category.Name,
+			Slug:     category.Slug,
+			ParentID: category.ParentID,
+			Children: []*CategoryResponse{},
+		}
+	}
+
+	roots := []*CategoryResponse{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID == nil || nodes[*category.ParentID] == nil {
+			roots = append(roots, node)
+			continue
+		}
+		parent := nodes[*category.ParentID]
+		parent.Children = append(parent.Children, node)
+	}
+
+	for _, root := range roots {
+		sumProductCounts(root, counts)
+	}
+	return roots
+}
+
+func sumProductCounts(node *CategoryResponse, counts map[uint]int64) int64 {
+	node.ProductCount = counts[node.ID]
+	for _, child := range node.Children {
+		node.ProductCount += sumProductCounts(child, counts)
+	}
+	return node.ProductCount
+}
+
+func NewCategoryResponse(category *Category) *CategoryResponse {
+	return &CategoryResponse{
+		ID:       category.ID,
+		Name:     category.Name,
+		Slug:     category.Slug,
+		ParentID: category.ParentID,
+		Children: []*CategoryResponse{},
+	}
+}
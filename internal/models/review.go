@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAlreadyReviewed is returned when the buyer has already reviewed the product
+var ErrAlreadyReviewed = errors.New("product already reviewed")
+
+// Review is a buyer's rating of a product they received. A buyer reviews a product once,
+// and the product's seller may answer it once.
+type Review struct {
+	gorm.Model
+	ProductID uint     `json:"product_id" gorm:"uniqueIndex:idx_review_product_user;not null"`
+	Product   *Product `json:"-"`
+	UserID    uint     `json:"user_id" gorm:"uniqueIndex:idx_review_product_user;not null"`
+	User      *User    `json:"-"`
+	// OrderID is the completed order that made the buyer eligible to review
+	OrderID uint   `json:"order_id" gorm:"not null"`
+	Rating  int    `json:"rating" gorm:"not null"`
+	Body    string `json:"body"`
+	// SellerResponse is empty until the seller answers
+	SellerResponse string     `json:"seller_response"`
+	RespondedAt    *time.Time `json:"responded_at"`
+}
+
+type CreateReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Body   string `json:"body" binding:"max=2000"`
+}
+
+type RespondToReviewRequest struct {
+	Response string `json:"response" binding:"required,max=2000"`
+}
+
+// ReviewSort is how a review listing is ordered
+type ReviewSort string
+
+const (
+	ReviewSortRecent     ReviewSort = "recent"
+	ReviewSortRatingHigh ReviewSort = "rating_desc"
+	ReviewSortRatingLow  ReviewSort = "rating_asc"
+)
+
+// ParseReviewSort accepts an empty value as the default, most recent first
+func ParseReviewSort(value string) (ReviewSort, bool) {
+	switch sort := ReviewSort(value); sort {
+	case "":
+		return ReviewSortRecent, true
+	case ReviewSortRecent, ReviewSortRatingHigh, ReviewSortRatingLow:
+		return sort, true
+	}
+	return "", false
+}
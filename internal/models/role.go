@@ -0,0 +1,12 @@
+package models
+
+// Role is a membership an Account can hold. Tokens are issued for one role at a time.
+// RoleUser is the buyer membership; it keeps the value "user" that tokens and stored
+// records already carry.
+type Role string
+
+const (
+	RoleUser   Role = "user"
+	RoleSeller Role = "seller"
+	RoleAdmin  Role = "admin"
+)
@@ -4,21 +4,29 @@ import (
 	"gorm.io/gorm"
 )
 
+// Seller is the seller membership of an Account, holding the store details
 type Seller struct {
 	gorm.Model
-	FirstName     string    `json:"first_name"`
-	LastName      string    `json:"last_name"`
-	Password      string    `json:"password"`
-	DateOfBirth   string    `json:"date_of_birth"`
-	Email         string    `json:"email"`
-	Phone         string    `json:"phone"`
-	Address       string    `json:"address"`
+	AccountID     uint      `json:"account_id" gorm:"uniqueIndex"`
+	Account       *Account  `json:"account"`
 	StoreName     string    `json:"store_name"`
 	StoreCategory string    `json:"store_category"`
 	Products      []Product `json:"products"`
 }
 
+type CreateSellerRequest struct {
+	SignupRequest
+	StoreName     string `json:"store_name" binding:"required"`
+	StoreCategory string `json:"store_category"`
+}
+
+// AddSellerRoleRequest opens a store for an account that already has the buyer role
+type AddSellerRoleRequest struct {
+	StoreName     string `json:"store_name" binding:"required"`
+	StoreCategory string `json:"store_category"`
+}
+
 type LoginRequestSeller struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is one login on one device. It owns a refresh-token family, and its
+// FamilyID travels in the sid claim of every access token issued from it.
+type Session struct {
+	gorm.Model
+	Role       Role      `json:"role" gorm:"index:idx_session_account;not null"`
+	AccountID  uint      `json:"account_id" gorm:"index:idx_session_account;not null"`
+	FamilyID   string    `json:"-" gorm:"uniqueIndex;not null"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// ExpiresAt follows the newest refresh token, after which the session cannot be renewed
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TwoFactor holds the TOTP settings embedded in Account. The secret is set on
+// enrollment but only enforced once Enabled is true, after the first code is confirmed.
+type TwoFactor struct {
+	TOTPSecret  string `json:"-"`
+	TOTPEnabled bool   `json:"totp_enabled"`
+	// TOTPLastCounter is the last accepted time step, so a code cannot be replayed
+	TOTPLastCounter int64 `json:"-"`
+}
+
+// RecoveryCode is a one-time code that can stand in for a TOTP code. Only its hash is stored.
+type RecoveryCode struct {
+	gorm.Model
+	AccountID uint       `json:"account_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginRequest completes a login with either a TOTP code or a recovery code
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
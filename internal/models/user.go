@@ -4,15 +4,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// User is the buyer membership of an Account
 type User struct {
 	gorm.Model
-	FirstName   string `json:"first_name"`
-	Lastname    string `json:"last_name"`
-	Password    string `json:"password"`
-	DateOfBirth string `json:"date_of_birth"`
-	Email       string `json:"email"`
-	Phone       string `json:"phone"`
-	Address     string `json:"address"`
+	AccountID uint     `json:"account_id" gorm:"uniqueIndex"`
+	Account   *Account `json:"account"`
+}
+
+type CreateUserRequest struct {
+	SignupRequest
 }
 
 type LoginRequestUser struct {
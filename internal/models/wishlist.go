@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Wishlist is a buyer's saved-for-later list. Unlike the cart it holds no quantities
+// and never counts towards a total.
+type Wishlist struct {
+	gorm.Model
+	UserID uint `json:"user_id" gorm:"uniqueIndex;not null"`
+	// ShareTokenHash is set while the owner shares a read-only link to the list
+	ShareTokenHash *string         `json:"-" gorm:"uniqueIndex"`
+	Items          []*WishlistItem `json:"items"`
+}
+
+// WishlistItem is one saved product. Removing it deletes the row, so the product can be saved again.
+type WishlistItem struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time `json:"created_at"`
+	WishlistID uint      `json:"wishlist_id" gorm:"uniqueIndex:idx_wishlist_product;not null"`
+	ProductID  uint      `json:"product_id" gorm:"uniqueIndex:idx_wishlist_product;not null"`
+	Product    *Product  `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+type WishlistItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package ports
+
+import "e-commerce/internal/models"
+
+type Mailer interface {
+	Send(message *models.MailMessage) error
+}
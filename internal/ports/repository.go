@@ -1,33 +1,119 @@
 package ports
 
-import "e-commerce/internal/models"
+import (
+	"e-commerce/internal/models"
+	"time"
+)
 
 type Repository interface {
+	FindAccountByEmail(email string) (*models.Account, error)
+	GetAccountByID(accountID uint) (*models.Account, error)
+	UpdateAccount(account *models.Account) error
+	DeleteAccount(accountID uint) error
 	FindUserByEmail(email string) (*models.User, error)
 	GetUserByID(userID uint) (*models.User, error)
+	GetUserByAccountID(accountID uint) (*models.User, error)
 	FindAllUsers() ([]models.User, error)
 	FindSellerByEmail(email string) (*models.Seller, error)
+	GetSellerByID(sellerID uint) (*models.Seller, error)
+	GetSellerByAccountID(accountID uint) (*models.Seller, error)
+	FindAdminByEmail(email string) (*models.Admin, error)
+	GetAdminByAccountID(accountID uint) (*models.Admin, error)
 	CreateUser(user *models.User) error
 	CreateSeller(Seller *models.Seller) error
+	CreateAdmin(admin *models.Admin) error
 	UpdateUser(user *models.User) error
 	UpdateSeller(user *models.Seller) error
 	BlacklistToken(token *models.BlacklistTokens) error
-	TokenInBlacklist(token *string) bool
-	GetAllProducts() ([]models.Product, error)
+	TokenInBlacklist(tokenID string) bool
+	PurgeExpiredBlacklistTokens() (int64, error)
+	CreateRefreshToken(token *models.RefreshToken) error
+	FindRefreshToken(tokenID string) (*models.RefreshToken, error)
+	UseRefreshToken(token *models.RefreshToken) error
+	RevokeRefreshTokenFamily(familyID string) error
+	CreateOneTimeToken(token *models.OneTimeToken) error
+	FindOneTimeToken(purpose models.TokenPurpose, tokenHash string) (*models.OneTimeToken, error)
+	ConsumeOneTimeToken(purpose models.TokenPurpose, tokenHash string) (*models.OneTimeToken, error)
+	AddPasswordHistory(entry *models.PasswordHistory, keep int) error
+	ListPasswordHistory(accountID uint, limit int) ([]models.PasswordHistory, error)
+	ReplaceRecoveryCodes(accountID uint, codes []*models.RecoveryCode) error
+	UseRecoveryCode(accountID uint, codeHash string) error
+	ListProducts(query *models.ProductQuery) ([]models.Product, int64, error)
+	SearchProducts(terms string, query *models.ProductQuery) ([]models.ProductSearchResult, int64, error)
 	GetProductByID(productID uint) (*models.Product, error)
 	AddProductToCart(cart *models.IndividualItemInCart) error
 	GetCartsByUserID(userID uint) ([]*models.IndividualItemInCart, error)
-	CreateOrder(order *models.Order) error
+	ListCartItems(userID uint) ([]*models.IndividualItemInCart, error)
+	CreateAddress(address *models.Address) error
+	ListAddresses(userID uint) ([]models.Address, error)
+	GetAddressByID(addressID uint) (*models.Address, error)
+	UpdateAddress(address *models.Address) error
+	DeleteAddress(address *models.Address) error
+	GetWishlist(userID uint) (*models.Wishlist, error)
+	GetWishlistByShareToken(tokenHash string) (*models.Wishlist, error)
+	AddToWishlist(userID, productID uint) error
+	RemoveFromWishlist(userID, productID uint) error
+	MoveWishlistItemToCart(userID uint, cart *models.IndividualItemInCart) error
+	SetWishlistShareToken(userID uint, tokenHash *string) error
+	CreateOrders(orders []*models.Order) error
 	CreateProduct(product *models.Product) error
 	DeleteProductFromCart(cart *models.IndividualItemInCart) error
 	GetOrdersByUserID(userID uint) ([]*models.Order, error)
-	GetCartItemByProductID(productID uint) (*models.IndividualItemInCart, error)
+	GetCartItemByProductID(userID, productID uint) (*models.IndividualItemInCart, error)
 	ListOrders(sellerID uint) ([]*models.Order, error)
 	GetProductsBySellerID(sellerID uint, products *[]models.Product) error
 	GetOrdersByProductID(productID uint, orders *[]models.Order) error
 	GetOrderByID(orderID uint) (*models.Order, error)
+	OrderBelongsToSeller(orderID, sellerID uint) (bool, error)
 	UpdateOrder(order *models.Order) error
+	DeclineOrder(order *models.Order, sellerID uint) error
+	UpdateProduct(product *models.Product) error
 	DeleteProduct(product *models.Product) error
+	CreateCategory(category *models.Category) error
+	GetCategoryByID(categoryID uint) (*models.Category, error)
+	ListCategories() ([]models.Category, error)
+	UpdateCategory(category *models.Category) error
+	DeleteCategory(category *models.Category) error
+	CountProductsByCategory(activeOnly bool) (map[uint]int64, error)
 	GetOrderItemsByOrderID(orderID uint) ([]*models.OrderItem, error)
 	ClearAll() error
+	RecordProductView(userID, productID uint) error
+	ListProductViews(userID uint) ([]models.ProductView, error)
+	ClearProductViews(userID uint) error
+	PurgeProductViews(cutoff time.Time) (int64, error)
+	GetCompletedOrderForProduct(userID, productID uint) (*models.Order, error)
+	GetReviewByUserAndProduct(userID, productID uint) (*models.Review, error)
+	GetReviewByID(reviewID uint) (*models.Review, error)
+	CreateReview(review *models.Review) error
+	RespondToReview(review *models.Review, response string) error
+	ListProductReviews(productID uint, sort models.ReviewSort) ([]models.Review, error)
+	ListSellerReviews(sellerID uint, sort models.ReviewSort) ([]models.Review, error)
+	ListReviewsByUserID(userID uint) ([]models.Review, error)
+	CreateNotifications(notifications []*models.Notification) error
+	ListNotifications(role models.Role, recipientID uint, unreadOnly bool, limit int) ([]models.Notification, error)
+	CountUnreadNotifications(role models.Role, recipientID uint) (int64, error)
+	MarkNotificationRead(role models.Role, recipientID, notificationID uint) error
+	MarkAllNotificationsRead(role models.Role, recipientID uint) (int64, error)
+	CreateSession(session *models.Session) error
+	TouchSession(role models.Role, familyID string) bool
+	ExtendSession(familyID string, expiresAt time.Time) error
+	ListSessions(accountID uint) ([]models.Session, error)
+	GetSessionByID(sessionID uint) (*models.Session, error)
+	GetSessionByFamilyID(familyID string) (*models.Session, error)
+	RevokeSession(session *models.Session) error
+	RevokeAllSessions(accountID uint) error
+	CreateAPIKey(key *models.APIKey) error
+	ListAPIKeys(sellerID uint) ([]models.APIKey, error)
+	GetAPIKeyByID(keyID uint) (*models.APIKey, error)
+	FindAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	RevokeAPIKey(key *models.APIKey) error
+	RevokeAccountAPIKeys(accountID uint) error
+	TouchAPIKey(key *models.APIKey)
+	GetLoginThrottle(key string) (*models.LoginThrottle, error)
+	RecordLoginFailure(key string, window time.Duration) (*models.LoginThrottle, error)
+	LockLogin(key string, until time.Time) error
+	ClearLoginFailures(key string) error
+	PurgeLoginThrottles(window time.Duration) (int64, error)
+	CreateAccountLockout(lockout *models.AccountLockout) error
+	ListAccountLockouts() ([]models.AccountLockout, error)
 }
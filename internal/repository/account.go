@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func (p *Postgres) FindAccountByEmail(email string) (*models.Account, error) {
+	account := &models.Account{}
+
+	if err := p.DB.Where("email = ?", email).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (p *Postgres) GetAccountByID(accountID uint) (*models.Account, error) {
+	account := &models.Account{}
+
+	if err := p.DB.Where("id = ?", accountID).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Update an account in the database. The token version is left alone, it only
+// changes through RevokeAllSessions.
+func (p *Postgres) UpdateAccount(account *models.Account) error {
+	if err := p.DB.Omit("token_version").Save(account).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// saveAccountProfile writes only the profile columns of an account, so a profile edit
+// cannot overwrite a password or second factor changed in the meantime
+func saveAccountProfile(tx *gorm.DB, account *models.Account) error {
+	if account == nil {
+		return nil
+	}
+	return tx.Model(account).
+		Select("first_name", "last_name", "date_of_birth", "phone", "address").
+		Updates(account).Error
+}
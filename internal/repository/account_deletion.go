@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// deletedEmail is the placeholder address a deleted account keeps, so its email can sign up again
+func deletedEmail(accountID uint) string {
+	return fmt.Sprintf("deleted-%d@deleted.invalid", accountID)
+}
+
+// DeleteAccount closes an account in every role it holds. Personal data is anonymised
+// and data only the account needed is removed, but orders stay, still pointing at the
+// anonymised memberships, so buyers and sellers keep their order history. A seller's
+// products are taken off sale and their API keys revoked.
+func (p *Postgres) DeleteAccount(accountID uint) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Account{}).Where("id = ?", accountID).Updates(map[string]interface{}{
+			"email":             deletedEmail(accountID),
+			"password":          "",
+			"first_name":        "Deleted",
+			"last_name":         "Account",
+			"date_of_birth":     "",
+			"phone":             "",
+			"address":           "",
+			"email_verified_at": nil,
+			"totp_secret":       "",
+			"totp_enabled":      false,
+			"totp_last_counter": 0,
+			"token_version":     gorm.Expr("token_version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		user := &models.User{}
+		if err := tx.Where("account_id = ?", accountID).Limit(1).Find(&user).Error; err != nil {
+			return err
+		}
+		if user.ID != 0 {
+			if err := tx.Where("user_id = ? AND order_id IS NULL", user.ID).Delete(&models.IndividualItemInCart{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Address{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("wishlist_id IN (?)", tx.Model(&models.Wishlist{}).Select("id").Where("user_id = ?", user.ID)).
+				Delete(&models.WishlistItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Wishlist{}).Error; err != nil {
+				return err
+			}
+			if err := deleteNotifications(tx, models.RoleUser, user.ID); err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ?", user.ID).Delete(&models.ProductView{}).Error; err != nil {
+				return err
+			}
+		}
+
+		seller := &models.Seller{}
+		if err := tx.Where("account_id = ?", accountID).Limit(1).Find(&seller).Error; err != nil {
+			return err
+		}
+		if seller.ID != 0 {
+			if err := tx.Model(&models.Product{}).Where("seller_id = ?", seller.ID).Update("status", false).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.APIKey{}).Where("seller_id = ? AND revoked_at IS NULL", seller.ID).Update("revoked_at", time.Now()).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Seller{}).Where("id = ?", seller.ID).Update("store_name", "Deleted store").Error; err != nil {
+				return err
+			}
+			if err := deleteNotifications(tx, models.RoleSeller, seller.ID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Session{}).
+			Where("account_id = ? AND revoked_at IS NULL", accountID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("account_id = ? AND revoked_at IS NULL", accountID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		for _, record := range []interface{}{&models.RecoveryCode{}, &models.OneTimeToken{}, &models.PasswordHistory{}} {
+			if err := tx.Unscoped().Where("account_id = ?", accountID).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, membership := range []interface{}{&models.User{}, &models.Seller{}, &models.Admin{}} {
+			if err := tx.Where("account_id = ?", accountID).Delete(membership).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Account{}, accountID).Error
+	})
+}
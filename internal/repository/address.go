@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateAddress adds an address to the user's address book. The first address a user
+// saves becomes the default.
+func (p *Postgres) CreateAddress(address *models.Address) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Address{}).Where("user_id = ?", address.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsDefault = true
+		}
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address.UserID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(address).Error
+	})
+}
+
+// ListAddresses returns the user's address book, default first
+func (p *Postgres) ListAddresses(userID uint) ([]models.Address, error) {
+	var addresses []models.Address
+	if err := p.DB.Where("user_id = ?", userID).Order("is_default DESC, created_at DESC").Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+func (p *Postgres) GetAddressByID(addressID uint) (*models.Address, error) {
+	address := &models.Address{}
+	if err := p.DB.Where("id = ?", addressID).First(&address).Error; err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// UpdateAddress saves an address. Making it the default clears the previous default.
+func (p *Postgres) UpdateAddress(address *models.Address) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address.UserID); err != nil {
+				return err
+			}
+		}
+		return tx.Save(address).Error
+	})
+}
+
+// DeleteAddress removes an address. If it was the default, the most recently added
+// remaining address takes its place.
+func (p *Postgres) DeleteAddress(address *models.Address) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		next := &models.Address{}
+		if err := tx.Where("user_id = ?", address.UserID).Order("created_at DESC").Limit(1).Find(&next).Error; err != nil {
+			return err
+		}
+		if next.ID == 0 {
+			return nil
+		}
+		return tx.Model(next).Update("is_default", true).Error
+	})
+}
+
+func clearDefaultAddress(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.Address{}).Where("user_id = ? AND is_default", userID).Update("is_default", false).Error
+}
@@ -0,0 +1,34 @@
+package repository
+
+import "e-commerce/internal/models"
+
+// FindAdminByEmail finds the admin membership of the account with this email
+func (p *Postgres) FindAdminByEmail(email string) (*models.Admin, error) {
+	admin := &models.Admin{}
+
+	if err := p.DB.Preload("Account").
+		Joins("JOIN accounts ON accounts.id = admins.account_id AND accounts.deleted_at IS NULL").
+		Where("accounts.email = ?", email).First(&admin).Error; err != nil {
+		return nil, err
+	}
+	return admin, nil
+}
+
+// GetAdminByAccountID returns the account's admin membership
+func (p *Postgres) GetAdminByAccountID(accountID uint) (*models.Admin, error) {
+	admin := &models.Admin{}
+
+	if err := p.DB.Preload("Account").Where("account_id = ?", accountID).First(&admin).Error; err != nil {
+		return nil, err
+	}
+	return admin, nil
+}
+
+// Create an admin in the database. A new admin.Account is created along with it;
+// an existing one just gains the admin membership.
+func (p *Postgres) CreateAdmin(admin *models.Admin) error {
+	if err := p.DB.Create(admin).Error; err != nil {
+		return err
+	}
+	return nil
+}
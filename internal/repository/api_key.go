@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+	"time"
+)
+
+// apiKeyTouchInterval limits how often a key's last-used time is written
+const apiKeyTouchInterval = time.Minute
+
+// Create an API key
+func (p *Postgres) CreateAPIKey(key *models.APIKey) error {
+	if err := p.DB.Create(key).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListAPIKeys returns a seller's keys that have not been revoked
+func (p *Postgres) ListAPIKeys(sellerID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := p.DB.Where("seller_id = ? AND revoked_at IS NULL", sellerID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (p *Postgres) GetAPIKeyByID(keyID uint) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	if err := p.DB.Where("id = ?", keyID).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// FindAPIKeyByHash looks up a key by the hash of its secret
+func (p *Postgres) FindAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	if err := p.DB.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (p *Postgres) RevokeAPIKey(key *models.APIKey) error {
+	return p.DB.Model(key).Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAccountAPIKeys revokes every live key of the account's seller membership, if it has one
+func (p *Postgres) RevokeAccountAPIKeys(accountID uint) error {
+	return p.DB.Model(&models.APIKey{}).
+		Where("seller_id IN (?) AND revoked_at IS NULL", p.DB.Model(&models.Seller{}).Select("id").Where("account_id = ?", accountID)).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchAPIKey records that a key was used, at most once per apiKeyTouchInterval
+func (p *Postgres) TouchAPIKey(key *models.APIKey) {
+	now := time.Now()
+	if key.LastUsedAt != nil && now.Sub(*key.LastUsedAt) < apiKeyTouchInterval {
+		return
+	}
+	p.DB.Model(key).UpdateColumn("last_used_at", now)
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// blacklistCacheSize bounds the number of jtis held in memory
+	blacklistCacheSize = 10000
+	// blacklistNegativeTTL is how long a "not blacklisted" answer is trusted before
+	// asking Postgres again, which bounds how long a logout on another instance goes unseen
+	blacklistNegativeTTL = 30 * time.Second
+)
+
+type blacklistCacheEntry struct {
+	blacklisted bool
+	until       time.Time
+}
+
+// blacklistCache answers TokenInBlacklist lookups without a query for recently seen tokens
+type blacklistCache struct {
+	mu      sync.Mutex
+	entries map[string]blacklistCacheEntry
+}
+
+func newBlacklistCache() *blacklistCache {
+	return &blacklistCache{
+		entries: make(map[string]blacklistCacheEntry),
+	}
+}
+
+// get returns the cached answer for tokenID and whether one was found
+func (c *blacklistCache) get(tokenID string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[tokenID]
+	if !ok {
+		return false, false
+	}
+	if time.Now().After(entry.until) {
+		delete(c.entries, tokenID)
+		return false, false
+	}
+	return entry.blacklisted, true
+}
+
+// set caches a blacklisted token until it expires, or a clean token for blacklistNegativeTTL
+func (c *blacklistCache) set(tokenID string, blacklisted bool, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	until := expiresAt
+	if !blacklisted {
+		until = time.Now().Add(blacklistNegativeTTL)
+	}
+
+	if len(c.entries) >= blacklistCacheSize {
+		c.evict(time.Now())
+	}
+	c.entries[tokenID] = blacklistCacheEntry{blacklisted: blacklisted, until: until}
+}
+
+// purge drops every entry that is no longer valid
+func (c *blacklistCache) purge(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, entry := range c.entries {
+		if now.After(entry.until) {
+			delete(c.entries, id)
+		}
+	}
+}
+
+// evict makes room for a new entry. Expired entries go first; if the cache is still
+// full, clean entries are dropped since forgetting them only costs a query.
+func (c *blacklistCache) evict(now time.Time) {
+	for id, entry := range c.entries {
+		if now.After(entry.until) {
+			delete(c.entries, id)
+		}
+	}
+	for id, entry := range c.entries {
+		if len(c.entries) < blacklistCacheSize {
+			return
+		}
+		if !entry.blacklisted {
+			delete(c.entries, id)
+		}
+	}
+	for id := range c.entries {
+		if len(c.entries) < blacklistCacheSize {
+			return
+		}
+		delete(c.entries, id)
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateCategory saves a new category. A slug already in use yields models.ErrCategorySlugTaken.
+func (p *Postgres) CreateCategory(category *models.Category) error {
+	return p.categoryError(p.DB.Create(category).Error)
+}
+
+func (p *Postgres) GetCategoryByID(categoryID uint) (*models.Category, error) {
+	category := &models.Category{}
+	if err := p.DB.Where("id = ?", categoryID).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// ListCategories returns every category ordered by name. The tree is small enough to
+// assemble in memory.
+func (p *Postgres) ListCategories() ([]models.Category, error) {
+	var categories []models.Category
+	if err := p.DB.Order("name ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// UpdateCategory saves a category. A slug already in use yields models.ErrCategorySlugTaken.
+func (p *Postgres) UpdateCategory(category *models.Category) error {
+	return p.categoryError(p.DB.Save(category).Error)
+}
+
+// categoryError maps a violation of the unique slug index to models.ErrCategorySlugTaken.
+// The index is the only unique constraint a category write can break besides the id.
+func (p *Postgres) categoryError(err error) error {
+	if err != nil && p.isDuplicateKey(err) {
+		return models.ErrCategorySlugTaken
+	}
+	return err
+}
+
+// DeleteCategory removes the row for good, so its slug can be used again
+func (p *Postgres) DeleteCategory(category *models.Category) error {
+	return p.DB.Unscoped().Delete(category).Error
+}
+
+// CountProductsByCategory counts the products filed directly under each category,
+// optionally only those on sale
+func (p *Postgres) CountProductsByCategory(activeOnly bool) (map[uint]int64, error) {
+	var rows []struct {
+		CategoryID uint
+		Count      int64
+	}
+	query := p.DB.Model(&models.Product{}).
+		Select("category_id, COUNT(*) AS count").
+		Where("category_id IS NOT NULL")
+	if activeOnly {
+		query = query.Where("status = ?", true)
+	}
+	if err := query.Group("category_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+	return counts, nil
+}
+
+// categorySubtree selects the ids of a category and all of its descendants
+func categorySubtree(categoryID uint) interface{} {
+	return gorm.Expr(`WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+		WHERE categories.deleted_at IS NULL
+	) SELECT id FROM subtree`, categoryID)
+}
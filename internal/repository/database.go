@@ -1,8 +1,8 @@
 package repository
 
 import (
-	"e-commerce/internal/models"
 	"e-commerce/internal/ports"
+	"errors"
 	"log"
 
 	"gorm.io/driver/postgres"
@@ -10,13 +10,15 @@ import (
 )
 
 type Postgres struct {
-	DB *gorm.DB
+	DB        *gorm.DB
+	blacklist *blacklistCache
 }
 
 // NewDB create/returns a new instance of our Database
 func NewDB(DB *gorm.DB) ports.Repository {
 	return &Postgres{
-		DB: DB,
+		DB:        DB,
+		blacklist: newBlacklistCache(),
 	}
 }
 
@@ -27,10 +29,18 @@ func Initialize(dbURI string) (*gorm.DB, error) {
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = conn.AutoMigrate(&models.User{}, &models.Seller{}, &models.BlacklistTokens{}, &models.Product{}, &models.Order{}, &models.OrderItem{}, &models.IndividualItemInCart{})
-	if err != nil {
+
+	if err := migrate(conn); err != nil {
 		return nil, err
 	}
 	log.Println("Database connection successful")
 	return conn, nil
 }
+
+// isDuplicateKey reports whether err is a unique constraint violation
+func (p *Postgres) isDuplicateKey(err error) bool {
+	if translator, ok := p.DB.Dialector.(gorm.ErrorTranslator); ok {
+		return errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetLoginThrottle returns the failure counter for key, or nil if it has none
+func (p *Postgres) GetLoginThrottle(key string) (*models.LoginThrottle, error) {
+	throttle := &models.LoginThrottle{}
+	if err := p.DB.Where("key = ?", key).First(&throttle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return throttle, nil
+}
+
+// RecordLoginFailure atomically increments the failure counter for key. Failures older
+// than window are forgotten, so the count restarts at one.
+func (p *Postgres) RecordLoginFailure(key string, window time.Duration) (*models.LoginThrottle, error) {
+	now := time.Now()
+	throttle := &models.LoginThrottle{}
+	err := p.DB.Raw(`
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING *`, key, now, now.Add(-window)).Scan(throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return throttle, nil
+}
+
+// LockLogin blocks logins for key until the given time
+func (p *Postgres) LockLogin(key string, until time.Time) error {
+	return p.DB.Model(&models.LoginThrottle{}).Where("key = ?", key).Update("locked_until", until).Error
+}
+
+// ClearLoginFailures forgets the failures recorded for key
+func (p *Postgres) ClearLoginFailures(key string) error {
+	return p.DB.Where("key = ?", key).Delete(&models.LoginThrottle{}).Error
+}
+
+// PurgeLoginThrottles deletes counters whose last failure is older than window and
+// whose lockout, if any, has ended. Such rows no longer slow anyone down.
+func (p *Postgres) PurgeLoginThrottles(window time.Duration) (int64, error) {
+	now := time.Now()
+	result := p.DB.Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", now.Add(-window), now).
+		Delete(&models.LoginThrottle{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// Save a lockout audit record
+func (p *Postgres) CreateAccountLockout(lockout *models.AccountLockout) error {
+	if err := p.DB.Create(lockout).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListAccountLockouts returns lockout records, most recent first
+func (p *Postgres) ListAccountLockouts() ([]models.AccountLockout, error) {
+	var lockouts []models.AccountLockout
+	if err := p.DB.Order("created_at DESC").Find(&lockouts).Error; err != nil {
+		return nil, err
+	}
+	return lockouts, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrate brings the schema up to date. Data fixes that AutoMigrate cannot express
+// run either before it (to make a change possible) or after it (to backfill new columns).
+func migrate(conn *gorm.DB) error {
+	// blacklist_tokens used to store raw tokens. Those tokens have no jti and are
+	// rejected by the middleware anyway, so the old rows can simply be dropped.
+	if conn.Migrator().HasColumn(&models.BlacklistTokens{}, "token") {
+		if err := conn.Exec("DELETE FROM blacklist_tokens").Error; err != nil {
+			return err
+		}
+		if err := conn.Migrator().DropColumn(&models.BlacklistTokens{}, "token"); err != nil {
+			return err
+		}
+	}
+
+	// Identities still stored on users, sellers and admins move to accounts after AutoMigrate
+	migrateIdentities := needsAccountMigration(conn)
+
+	err := conn.AutoMigrate(&models.Account{}, &models.User{}, &models.Seller{}, &models.Admin{}, &models.BlacklistTokens{}, &models.RefreshToken{}, &models.OneTimeToken{}, &models.PasswordHistory{}, &models.LoginThrottle{}, &models.AccountLockout{}, &models.RecoveryCode{}, &models.Session{}, &models.APIKey{}, &models.Address{}, &models.Category{}, &models.Product{}, &models.Order{}, &models.OrderItem{}, &models.IndividualItemInCart{}, &models.Wishlist{}, &models.WishlistItem{}, &models.Notification{}, &models.Review{}, &models.ProductView{})
+	if err != nil {
+		return err
+	}
+
+	if err := migrateProductSearch(conn); err != nil {
+		return err
+	}
+
+	if migrateIdentities {
+		if err := migrateAccounts(conn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
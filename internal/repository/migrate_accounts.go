@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// legacyIdentity is a users, sellers or admins row from before accounts existed,
+// when each of those tables carried its own email, password and second factor
+type legacyIdentity struct {
+	Role            models.Role
+	ID              uint
+	Email           string
+	Password        string
+	FirstName       string
+	LastName        string
+	DateOfBirth     string
+	Phone           string
+	Address         string
+	EmailVerifiedAt *time.Time
+	TOTPSecret      string
+	TOTPEnabled     bool
+	TOTPLastCounter int64
+	TokenVersion    uint
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       *time.Time
+}
+
+// legacyIdentityTables maps each membership table to the column its last name was stored in
+var legacyIdentityTables = []struct {
+	role     models.Role
+	table    string
+	lastName string
+}{
+	{models.RoleUser, "users", "lastname"},
+	{models.RoleSeller, "sellers", "last_name"},
+	{models.RoleAdmin, "admins", "last_name"},
+}
+
+// legacyIdentityColumns are dropped from the membership tables once copied to accounts
+var legacyIdentityColumns = []string{
+	"email", "password", "first_name", "last_name", "lastname", "date_of_birth", "phone", "address",
+	"email_verified_at", "totp_secret", "totp_enabled", "totp_last_counter", "token_version",
+}
+
+// needsAccountMigration reports whether any membership table still holds identity columns
+func needsAccountMigration(conn *gorm.DB) bool {
+	for _, legacy := range legacyIdentityTables {
+		if conn.Migrator().HasTable(legacy.table) && conn.Migrator().HasColumn(legacy.table, "email") {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateAccounts moves identities out of users, sellers and admins into accounts. It
+// runs after AutoMigrate has created accounts and the account_id columns.
+//
+// Rows sharing an email become one account holding each of their memberships. The
+// account takes its password and second factor from one row: a live row wins over a
+// deleted one, a verified email over an unverified one, then the most recently updated
+// row. The other rows' recovery codes, password history and one-time tokens are deleted,
+// since they belong to credentials that no longer exist. Two live rows of the same table
+// sharing an email stop the migration, since one account cannot hold both.
+//
+// Tokens name their account in the sub claim, which used to be a membership ID, so every
+// session and refresh token is revoked and everyone signs in again.
+func migrateAccounts(conn *gorm.DB) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		var identities []*legacyIdentity
+		for _, legacy := range legacyIdentityTables {
+			if !tx.Migrator().HasTable(legacy.table) || !tx.Migrator().HasColumn(legacy.table, "email") {
+				continue
+			}
+			rows, err := loadLegacyIdentities(tx, legacy.role, legacy.table, legacy.lastName)
+			if err != nil {
+				return err
+			}
+			identities = append(identities, rows...)
+		}
+
+		byEmail := make(map[string][]*legacyIdentity)
+		for _, identity := range identities {
+			byEmail[identity.Email] = append(byEmail[identity.Email], identity)
+		}
+		emails := make([]string, 0, len(byEmail))
+		for email := range byEmail {
+			emails = append(emails, email)
+		}
+		sort.Strings(emails)
+
+		// One account holds at most one membership per role, so two live rows of one table
+		// sharing an email cannot both be linked. Their identity columns are about to be
+		// dropped, so stop instead of leaving one of them unable to sign in.
+		var conflicts []string
+		for _, email := range emails {
+			live := make(map[models.Role][]string)
+			for _, identity := range byEmail[email] {
+				if identity.DeletedAt == nil {
+					live[identity.Role] = append(live[identity.Role], fmt.Sprint(identity.ID))
+				}
+			}
+			for _, legacy := range legacyIdentityTables {
+				if ids := live[legacy.role]; len(ids) > 1 {
+					conflicts = append(conflicts, fmt.Sprintf("%s %s share the email %q", legacy.table, strings.Join(ids, ", "), email))
+				}
+			}
+		}
+		if len(conflicts) > 0 {
+			return fmt.Errorf("migrate accounts: rows in one table share an email and cannot become one account; "+
+				"give each row its own email or delete the extra rows, then restart: %s", strings.Join(conflicts, "; "))
+		}
+
+		// accountFor maps "<role>:<old membership id>" to the new account, for the rows
+		// whose credentials the account kept
+		accountFor := make(map[string]uint)
+		// members maps the same key for every row, including those whose credentials were dropped
+		members := make(map[string]uint)
+
+		for _, email := range emails {
+			group := byEmail[email]
+			sort.SliceStable(group, func(i, j int) bool {
+				iLive, jLive := group[i].DeletedAt == nil, group[j].DeletedAt == nil
+				if iLive != jLive {
+					return iLive
+				}
+				iVerified, jVerified := group[i].EmailVerifiedAt != nil, group[j].EmailVerifiedAt != nil
+				if iVerified != jVerified {
+					return iVerified
+				}
+				return group[i].UpdatedAt.After(group[j].UpdatedAt)
+			})
+			winner := group[0]
+
+			account := &models.Account{
+				Email:           winner.Email,
+				Password:        winner.Password,
+				FirstName:       winner.FirstName,
+				LastName:        winner.LastName,
+				DateOfBirth:     winner.DateOfBirth,
+				Phone:           winner.Phone,
+				Address:         winner.Address,
+				EmailVerifiedAt: winner.EmailVerifiedAt,
+				TwoFactor: models.TwoFactor{
+					TOTPSecret:      winner.TOTPSecret,
+					TOTPEnabled:     winner.TOTPEnabled,
+					TOTPLastCounter: winner.TOTPLastCounter,
+				},
+				TokenVersion: winner.TokenVersion,
+			}
+			account.CreatedAt = winner.CreatedAt
+			for _, identity := range group {
+				if identity.CreatedAt.Before(account.CreatedAt) {
+					account.CreatedAt = identity.CreatedAt
+				}
+			}
+			// The account is only deleted if every membership was
+			alive := false
+			for _, identity := range group {
+				alive = alive || identity.DeletedAt == nil
+			}
+			if !alive && winner.DeletedAt != nil {
+				account.DeletedAt = gorm.DeletedAt{Time: *winner.DeletedAt, Valid: true}
+			}
+			if err := tx.Create(account).Error; err != nil {
+				return err
+			}
+
+			linked := make(map[models.Role]bool)
+			for _, identity := range group {
+				key := fmt.Sprintf("%s:%d", identity.Role, identity.ID)
+				members[key] = account.ID
+				if identity == winner {
+					accountFor[key] = account.ID
+				}
+
+				// Only deleted rows can repeat a role here, see the conflict check above. Live
+				// rows sort first, so the live one is linked and the deleted ones are not.
+				if linked[identity.Role] {
+					log.Printf("migrate accounts: deleted %s %d shares its email with another %s and was not linked\n", identity.Role, identity.ID, identity.Role)
+					continue
+				}
+				linked[identity.Role] = true
+				if err := tx.Table(tableForRole(identity.Role)).Where("id = ?", identity.ID).
+					Update("account_id", account.ID).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := remapAccountRecords(tx, "recovery_codes", accountFor); err != nil {
+			return err
+		}
+		if err := remapAccountRecords(tx, "password_histories", accountFor); err != nil {
+			return err
+		}
+		if err := remapAccountRecords(tx, "one_time_tokens", accountFor); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Session{}).Where("revoked_at IS NULL").Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RefreshToken{}).Where("revoked_at IS NULL").Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		// Recovery codes and password history now belong to the account, not a role
+		for _, table := range []string{"recovery_codes", "password_histories"} {
+			if tx.Migrator().HasColumn(table, "role") {
+				if err := tx.Migrator().DropColumn(table, "role"); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, legacy := range legacyIdentityTables {
+			for _, column := range legacyIdentityColumns {
+				if tx.Migrator().HasTable(legacy.table) && tx.Migrator().HasColumn(legacy.table, column) {
+					if err := tx.Migrator().DropColumn(legacy.table, column); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		log.Printf("migrate accounts: %d memberships merged into %d accounts\n", len(members), len(emails))
+		return nil
+	})
+}
+
+func loadLegacyIdentities(tx *gorm.DB, role models.Role, table string, lastName string) ([]*legacyIdentity, error) {
+	// Columns added by later changes may be missing from older tables
+	column := func(name string, fallback string) string {
+		if tx.Migrator().HasColumn(table, name) {
+			return name
+		}
+		return fallback
+	}
+
+	query := fmt.Sprintf(`SELECT id, email, password, first_name, %s AS last_name,
+		%s AS date_of_birth, %s AS phone, %s AS address,
+		%s AS email_verified_at, %s AS totp_secret, %s AS totp_enabled, %s AS totp_last_counter,
+		%s AS token_version, created_at, updated_at, deleted_at
+		FROM %s WHERE account_id IS NULL ORDER BY id`,
+		column(lastName, "''"),
+		column("date_of_birth", "''"), column("phone", "''"), column("address", "''"),
+		// Accounts from before email verification existed are treated as verified
+		column("email_verified_at", "created_at"),
+		column("totp_secret", "''"), column("totp_enabled", "false"), column("totp_last_counter", "0"),
+		column("token_version", "0"), table)
+
+	var rows []*legacyIdentity
+	if err := tx.Raw(query).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		row.Role = role
+		row.Email = strings.TrimSpace(row.Email)
+	}
+	return rows, nil
+}
+
+// remapAccountRecords points rows keyed by (role, membership id) at their new account and
+// deletes the rows of memberships whose credentials were not kept
+func remapAccountRecords(tx *gorm.DB, table string, accountFor map[string]uint) error {
+	if !tx.Migrator().HasTable(table) || !tx.Migrator().HasColumn(table, "role") {
+		return nil
+	}
+
+	var records []struct {
+		ID        uint
+		Role      models.Role
+		AccountID uint
+	}
+	if err := tx.Table(table).Select("id, role, account_id").Scan(&records).Error; err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		accountID, ok := accountFor[fmt.Sprintf("%s:%d", record.Role, record.AccountID)]
+		if !ok {
+			if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", table), record.ID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tx.Table(table).Where("id = ?", record.ID).Update("account_id", accountID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tableForRole(role models.Role) string {
+	switch role {
+	case models.RoleSeller:
+		return "sellers"
+	case models.RoleAdmin:
+		return "admins"
+	}
+	return "users"
+}
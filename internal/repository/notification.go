@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (p *Postgres) CreateNotifications(notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return p.DB.Create(notifications).Error
+}
+
+// ListNotifications returns a recipient's notifications, newest first
+func (p *Postgres) ListNotifications(role models.Role, recipientID uint, unreadOnly bool, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := p.DB.Where("role = ? AND recipient_id = ?", role, recipientID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (p *Postgres) CountUnreadNotifications(role models.Role, recipientID uint) (int64, error) {
+	var count int64
+	err := p.DB.Model(&models.Notification{}).
+		Where("role = ? AND recipient_id = ? AND read_at IS NULL", role, recipientID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkNotificationRead returns gorm.ErrRecordNotFound if the notification does not
+// belong to the recipient. Marking a read notification again is a no-op.
+func (p *Postgres) MarkNotificationRead(role models.Role, recipientID, notificationID uint) error {
+	notification := &models.Notification{}
+	if err := p.DB.Where("id = ? AND role = ? AND recipient_id = ?", notificationID, role, recipientID).
+		First(&notification).Error; err != nil {
+		return err
+	}
+	if notification.ReadAt != nil {
+		return nil
+	}
+	return p.DB.Model(notification).Update("read_at", time.Now()).Error
+}
+
+// MarkAllNotificationsRead returns how many notifications were unread
+func (p *Postgres) MarkAllNotificationsRead(role models.Role, recipientID uint) (int64, error) {
+	result := p.DB.Model(&models.Notification{}).
+		Where("role = ? AND recipient_id = ? AND read_at IS NULL", role, recipientID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// deleteNotifications removes everything addressed to a membership that is being deleted
+func deleteNotifications(tx *gorm.DB, role models.Role, recipientID uint) error {
+	return tx.Unscoped().Where("role = ? AND recipient_id = ?", role, recipientID).Delete(&models.Notification{}).Error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AddPasswordHistory records a password hash and keeps only the account's latest keep entries
+func (p *Postgres) AddPasswordHistory(entry *models.PasswordHistory, keep int) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		keepIDs := tx.Model(&models.PasswordHistory{}).Select("id").
+			Where("account_id = ?", entry.AccountID).
+			Order("id DESC").Limit(keep)
+		return tx.Unscoped().
+			Where("account_id = ? AND id NOT IN (?)", entry.AccountID, keepIDs).
+			Delete(&models.PasswordHistory{}).Error
+	})
+}
+
+// ListPasswordHistory returns an account's latest limit password hashes, newest first
+func (p *Postgres) ListPasswordHistory(accountID uint, limit int) ([]models.PasswordHistory, error) {
+	var history []models.PasswordHistory
+	if err := p.DB.Where("account_id = ?", accountID).
+		Order("id DESC").Limit(limit).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
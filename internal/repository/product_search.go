@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxSearchTerms bounds the size of the tsquery built from user input
+	maxSearchTerms = 10
+	// headlineOptions marks matches with <mark> and keeps snippets short. The text is
+	// HTML-escaped first, see escapeHTML.
+	headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2"
+)
+
+// migrateProductSearch adds the full-text search column and its GIN index. The column is
+// generated by Postgres, so it stays current on every insert and update without any
+// application code; Product does not map it, so gorm never tries to write it.
+func migrateProductSearch(conn *gorm.DB) error {
+	if !conn.Migrator().HasColumn(&models.Product{}, "search_vector") {
+		err := conn.Exec(`ALTER TABLE products ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(overview, '')), 'B') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'C')
+		) STORED`).Error
+		if err != nil {
+			return err
+		}
+	}
+	return conn.Exec("CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)").Error
+}
+
+// SearchProducts ranks products matching the search terms, applying the catalogue
+// filters on top. Each term matches as a prefix, so "lap" finds "laptop". Results are
+// ordered by relevance unless the query asks for another sort.
+func (p *Postgres) SearchProducts(terms string, query *models.ProductQuery) ([]models.ProductSearchResult, int64, error) {
+	tsquery := prefixTSQuery(terms)
+	if tsquery == "" {
+		return []models.ProductSearchResult{}, 0, nil
+	}
+
+	var total int64
+	matching := func() *gorm.DB {
+		return filterProducts(p.DB.Model(&models.Product{}), query).
+			Where("products.search_vector @@ to_tsquery('english', ?)", tsquery)
+	}
+	if err := matching().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	search := matching().Select(`products.*,
+		ts_rank_cd(products.search_vector, to_tsquery('english', ?)) AS rank,
+		ts_headline('english', `+escapeHTML("products.title")+`, to_tsquery('english', ?), ?) AS title_highlight,
+		ts_headline('english', `+escapeHTML("concat_ws(' ', products.overview, products.description)")+`, to_tsquery('english', ?), ?) AS snippet`,
+		tsquery, tsquery, headlineOptions, tsquery, headlineOptions)
+	if query.Sort == models.ProductSortRelevance {
+		search = search.Order("rank DESC, products.id ASC")
+	} else {
+		search = sortProducts(search, query.Sort)
+	}
+
+	var results []models.ProductSearchResult
+	if err := search.Offset(query.Offset()).Limit(query.Limit).Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// escapeHTML wraps a SQL text expression so that it is HTML-escaped. Product text is
+// written by sellers, so it is escaped before ts_headline adds <mark>, leaving those
+// tags as the only markup in a highlight. The text search parser reads each entity as
+// one token, so the words around it are still highlighted.
+func escapeHTML(expr string) string {
+	for _, r := range []struct{ from, to string }{
+		{"&", "&amp;"}, {"<", "&lt;"}, {">", "&gt;"}, {`"`, "&quot;"},
+	} {
+		expr = "replace(" + expr + ", '" + r.from + "', '" + r.to + "')"
+	}
+	return expr
+}
+
+// prefixTSQuery turns free text into a tsquery that requires every word as a prefix,
+// e.g. "red shoe" becomes "red:* & shoe:*". Only letters and digits survive, so user
+// input can never be parsed as tsquery syntax.
+func prefixTSQuery(terms string) string {
+	words := strings.FieldsFunc(strings.ToLower(terms), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const historyPageSize = 50
+
+// RecordProductView notes that the user viewed the product just now
+func (p *Postgres) RecordProductView(userID, productID uint) error {
+	view := &models.ProductView{UserID: userID, ProductID: productID, Views: 1, ViewedAt: time.Now()}
+	return p.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "product_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"viewed_at": view.ViewedAt,
+			"views":     gorm.Expr("product_views.views + 1"),
+		}),
+	}).Create(view).Error
+}
+
+// ListProductViews returns the user's most recently viewed products
+func (p *Postgres) ListProductViews(userID uint) ([]models.ProductView, error) {
+	var views []models.ProductView
+	if err := p.DB.Preload("Product").Where("user_id = ?", userID).
+		Order("viewed_at DESC").Limit(historyPageSize).Find(&views).Error; err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+func (p *Postgres) ClearProductViews(userID uint) error {
+	return p.DB.Where("user_id = ?", userID).Delete(&models.ProductView{}).Error
+}
+
+// PurgeProductViews deletes views older than cutoff, keeping history within its retention
+func (p *Postgres) PurgeProductViews(cutoff time.Time) (int64, error) {
+	result := p.DB.Where("viewed_at < ?", cutoff).Delete(&models.ProductView{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const reviewPageSize = 100
+
+// GetCompletedOrderForProduct finds the buyer's most recent completed order containing
+// the product. A buyer may only review products they have received.
+func (p *Postgres) GetCompletedOrderForProduct(userID, productID uint) (*models.Order, error) {
+	order := &models.Order{}
+	if err := p.DB.
+		Joins("JOIN order_items ON order_items.order_id = orders.id AND order_items.deleted_at IS NULL").
+		Where("orders.user_id = ? AND orders.status = ? AND order_items.product_id = ?", userID, models.COMPLETED, productID).
+		Order("orders.updated_at DESC").
+		First(&order).Error; err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (p *Postgres) GetReviewByUserAndProduct(userID, productID uint) (*models.Review, error) {
+	review := &models.Review{}
+	if err := p.DB.Where("user_id = ? AND product_id = ?", userID, productID).First(&review).Error; err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (p *Postgres) GetReviewByID(reviewID uint) (*models.Review, error) {
+	review := &models.Review{}
+	if err := p.DB.Preload("Product").Preload("User.Account").Where("id = ?", reviewID).First(&review).Error; err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// CreateReview saves a review and refreshes the product's rating summary in the same
+// transaction, so the average always matches the reviews. A second review of the same
+// product by the same buyer yields models.ErrAlreadyReviewed.
+func (p *Postgres) CreateReview(review *models.Review) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			if p.isDuplicateKey(err) {
+				return models.ErrAlreadyReviewed
+			}
+			return err
+		}
+		return tx.Model(&models.Product{}).Where("id = ?", review.ProductID).Updates(map[string]interface{}{
+			"rating_count":   gorm.Expr("(SELECT COUNT(*) FROM reviews WHERE product_id = ? AND deleted_at IS NULL)", review.ProductID),
+			"rating_average": gorm.Expr("(SELECT COALESCE(AVG(rating), 0) FROM reviews WHERE product_id = ? AND deleted_at IS NULL)", review.ProductID),
+		}).Error
+	})
+}
+
+// RespondToReview records the seller's answer. It returns gorm.ErrRecordNotFound if
+// the review has already been answered.
+func (p *Postgres) RespondToReview(review *models.Review, response string) error {
+	now := time.Now()
+	result := p.DB.Model(&models.Review{}).
+		Where("id = ? AND responded_at IS NULL", review.ID).
+		Updates(map[string]interface{}{"seller_response": response, "responded_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	review.SellerResponse = response
+	review.RespondedAt = &now
+	return nil
+}
+
+// ListProductReviews returns a product's reviews in the requested order
+func (p *Postgres) ListProductReviews(productID uint, sort models.ReviewSort) ([]models.Review, error) {
+	var reviews []models.Review
+	if err := reviewQuery(p.DB, sort).Where("reviews.product_id = ?", productID).Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// ListSellerReviews returns the reviews of every product the seller sells
+func (p *Postgres) ListSellerReviews(sellerID uint, sort models.ReviewSort) ([]models.Review, error) {
+	var reviews []models.Review
+	if err := reviewQuery(p.DB, sort).
+		Joins("JOIN products ON products.id = reviews.product_id").
+		Where("products.seller_id = ?", sellerID).
+		Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// ListReviewsByUserID returns the reviews a buyer has written, newest first
+func (p *Postgres) ListReviewsByUserID(userID uint) ([]models.Review, error) {
+	var reviews []models.Review
+	if err := reviewQuery(p.DB, models.ReviewSortRecent).Where("reviews.user_id = ?", userID).Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func reviewQuery(db *gorm.DB, sort models.ReviewSort) *gorm.DB {
+	query := db.Preload("User.Account").Limit(reviewPageSize)
+	switch sort {
+	case models.ReviewSortRatingHigh:
+		return query.Order("reviews.rating DESC, reviews.created_at DESC")
+	case models.ReviewSortRatingLow:
+		return query.Order("reviews.rating ASC, reviews.created_at DESC")
+	}
+	return query.Order("reviews.created_at DESC")
+}
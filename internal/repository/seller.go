@@ -1,17 +1,44 @@
 package repository
 
-import "e-commerce/internal/models"
+import (
+	"e-commerce/internal/models"
 
+	"gorm.io/gorm"
+)
+
+// FindSellerByEmail finds the seller membership of the account with this email
 func (p *Postgres) FindSellerByEmail(email string) (*models.Seller, error) {
 	seller := &models.Seller{}
 
-	if err := p.DB.Where("email = ?", email).First(&seller).Error; err != nil {
+	if err := p.DB.Preload("Account").
+		Joins("JOIN accounts ON accounts.id = sellers.account_id AND accounts.deleted_at IS NULL").
+		Where("accounts.email = ?", email).First(&seller).Error; err != nil {
+		return nil, err
+	}
+	return seller, nil
+}
+
+func (p *Postgres) GetSellerByID(sellerID uint) (*models.Seller, error) {
+	seller := &models.Seller{}
+
+	if err := p.DB.Preload("Account").Where("ID = ?", sellerID).First(&seller).Error; err != nil {
+		return nil, err
+	}
+	return seller, nil
+}
+
+// GetSellerByAccountID returns the account's seller membership
+func (p *Postgres) GetSellerByAccountID(accountID uint) (*models.Seller, error) {
+	seller := &models.Seller{}
+
+	if err := p.DB.Preload("Account").Where("account_id = ?", accountID).First(&seller).Error; err != nil {
 		return nil, err
 	}
 	return seller, nil
 }
 
-// Create a user in the database
+// Create a seller in the database. A new seller.Account is created along with it;
+// an existing one just gains the seller membership.
 func (p *Postgres) CreateSeller(seller *models.Seller) error {
 	if err := p.DB.Create(seller).Error; err != nil {
 		return err
@@ -19,12 +46,15 @@ func (p *Postgres) CreateSeller(seller *models.Seller) error {
 	return nil
 }
 
-// Update a user in the database
+// Update a seller and the profile fields of its account in the database.
+// Credentials are saved with UpdateAccount.
 func (p *Postgres) UpdateSeller(seller *models.Seller) error {
-	if err := p.DB.Save(seller).Error; err != nil {
-		return err
-	}
-	return nil
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Account").Save(seller).Error; err != nil {
+			return err
+		}
+		return saveAccountProfile(tx, seller.Account)
+	})
 }
 
 // create a product in the database
@@ -60,6 +90,21 @@ func (p *Postgres) GetOrdersByProductID(productID uint, orders *[]models.Order)
 		Find(orders).Error
 }
 
+// OrderBelongsToSeller reports whether every item of the order is one of the seller's
+// products, counting products the seller has since deleted
+func (p *Postgres) OrderBelongsToSeller(orderID, sellerID uint) (bool, error) {
+	var counts struct {
+		Items int64
+		Owned int64
+	}
+	err := p.DB.Model(&models.OrderItem{}).
+		Select("COUNT(*) AS items, COUNT(*) FILTER (WHERE products.seller_id = ?) AS owned", sellerID).
+		Joins("LEFT JOIN products ON products.id = order_items.product_id").
+		Where("order_items.order_id = ?", orderID).
+		Scan(&counts).Error
+	return counts.Items > 0 && counts.Owned == counts.Items, err
+}
+
 func (p *Postgres) GetOrderByID(orderID uint) (*models.Order, error) {
 	order := &models.Order{}
 
@@ -76,11 +121,53 @@ func (p *Postgres) UpdateOrder(order *models.Order) error {
 	return nil
 }
 
+// DeclineOrder marks a placed order declined and puts the seller's units of it back in
+// stock. An order that is no longer placed returns gorm.ErrRecordNotFound and changes nothing.
+func (p *Postgres) DeclineOrder(order *models.Order, sellerID uint) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Order{}).
+			Where("id = ? AND status = ?", order.ID, models.PLACED).
+			Update("status", models.DECLINED)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var items []models.OrderItem
+		if err := tx.Joins("JOIN products ON products.id = order_items.product_id").
+			Where("order_items.order_id = ? AND products.seller_id = ?", order.ID, sellerID).
+			Find(&items).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := tx.Model(&models.Product{}).Where("id = ?", item.ProductID).
+				Update("quantity", gorm.Expr("quantity + ?", item.Quantity)).Error; err != nil {
+				return err
+			}
+		}
+		order.Status = models.DECLINED
+		return nil
+	})
+}
+
+// UpdateProduct saves the fields a seller can edit
+func (p *Postgres) UpdateProduct(product *models.Product) error {
+	return p.DB.Model(product).
+		Select("category_id", "title", "image_url", "price", "quantity", "overview", "description", "status").
+		Updates(product).Error
+}
+
+// DeleteProduct removes a product and takes it out of every open cart. Orders keep
+// pointing at the deleted row.
 func (p *Postgres) DeleteProduct(product *models.Product) error {
-	if err := p.DB.Delete(product).Error; err != nil {
-		return err
-	}
-	return nil
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ? AND order_id IS NULL", product.ID).Delete(&models.IndividualItemInCart{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(product).Error
+	})
 }
 
 // clear all products, orders and order items
@@ -91,6 +178,15 @@ func (p *Postgres) ClearAll() error {
 	if err := p.DB.Exec("DELETE FROM orders").Error; err != nil {
 		return err
 	}
+	if err := p.DB.Exec("DELETE FROM product_views").Error; err != nil {
+		return err
+	}
+	if err := p.DB.Exec("DELETE FROM reviews").Error; err != nil {
+		return err
+	}
+	if err := p.DB.Exec("DELETE FROM wishlist_items").Error; err != nil {
+		return err
+	}
 	if err := p.DB.Exec("DELETE FROM products").Error; err != nil {
 		return err
 	}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval limits how often a session's last-seen time is written
+const sessionTouchInterval = time.Minute
+
+// Create a session for a new login
+func (p *Postgres) CreateSession(session *models.Session) error {
+	if err := p.DB.Create(session).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// TouchSession reports whether a session is active and, at most once per
+// sessionTouchInterval, moves its last-seen time forward
+func (p *Postgres) TouchSession(role models.Role, familyID string) bool {
+	session := &models.Session{}
+	now := time.Now()
+	if err := p.DB.Where("role = ? AND family_id = ? AND revoked_at IS NULL AND expires_at > ?", role, familyID, now).
+		First(&session).Error; err != nil {
+		return false
+	}
+
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		p.DB.Model(session).UpdateColumn("last_seen_at", now)
+	}
+	return true
+}
+
+// ExtendSession records a refresh on the session owning familyID
+func (p *Postgres) ExtendSession(familyID string, expiresAt time.Time) error {
+	return p.DB.Model(&models.Session{}).
+		Where("family_id = ?", familyID).
+		Updates(map[string]interface{}{"expires_at": expiresAt, "last_seen_at": time.Now()}).Error
+}
+
+// ListSessions returns the active sessions of an account in every role, most recently used first
+func (p *Postgres) ListSessions(accountID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := p.DB.Where("account_id = ? AND revoked_at IS NULL AND expires_at > ?", accountID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (p *Postgres) GetSessionByID(sessionID uint) (*models.Session, error) {
+	session := &models.Session{}
+	if err := p.DB.Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (p *Postgres) GetSessionByFamilyID(familyID string) (*models.Session, error) {
+	session := &models.Session{}
+	if err := p.DB.Where("family_id = ?", familyID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// RevokeSession ends one session along with its refresh tokens
+func (p *Postgres) RevokeSession(session *models.Session) error {
+	now := time.Now()
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", session.ID).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", session.FamilyID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// RevokeAllSessions ends every session of an account, in every role, and bumps its
+// token version, so access tokens already handed out stop working immediately
+func (p *Postgres) RevokeAllSessions(accountID uint) error {
+	now := time.Now()
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).
+			Where("account_id = ? AND revoked_at IS NULL", accountID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("account_id = ? AND revoked_at IS NULL", accountID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Account{}).Where("id = ?", accountID).
+			UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+	})
+}
@@ -1,20 +1,164 @@
 package repository
 
-import "e-commerce/internal/models"
+import (
+	"e-commerce/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Save Blacklist token in the blacklistToken collection
 func (p *Postgres) BlacklistToken(token *models.BlacklistTokens) error {
 	if err := p.DB.Create(token).Error; err != nil {
 		return err
 	}
+	p.blacklist.set(token.TokenID, true, token.ExpiresAt)
 	return nil
 }
 
-// TokenInBlacklist checks if token is already in the blacklist collection
-func (p *Postgres) TokenInBlacklist(token *string) bool {
+// TokenInBlacklist checks if the token with this jti has been blacklisted.
+// Answers are cached in memory so most requests never reach Postgres.
+func (p *Postgres) TokenInBlacklist(tokenID string) bool {
+	if blacklisted, found := p.blacklist.get(tokenID); found {
+		return blacklisted
+	}
+
 	blacklistToken := &models.BlacklistTokens{}
-	if err := p.DB.Where("token = ?", token).First(&blacklistToken).Error; err != nil {
+	err := p.DB.Where("token_id = ?", tokenID).First(&blacklistToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			p.blacklist.set(tokenID, false, time.Time{})
+		}
 		return false
 	}
+	p.blacklist.set(tokenID, true, blacklistToken.ExpiresAt)
 	return true
 }
+
+// PurgeExpiredBlacklistTokens deletes blacklist rows for tokens that have expired on their own
+func (p *Postgres) PurgeExpiredBlacklistTokens() (int64, error) {
+	now := time.Now()
+	result := p.DB.Unscoped().Where("expires_at < ?", now).Delete(&models.BlacklistTokens{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	p.blacklist.purge(now)
+	return result.RowsAffected, nil
+}
+
+// Save a newly issued refresh token
+func (p *Postgres) CreateRefreshToken(token *models.RefreshToken) error {
+	if err := p.DB.Create(token).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindRefreshToken looks up a refresh token by its jti
+func (p *Postgres) FindRefreshToken(tokenID string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	if err := p.DB.Where("token_id = ?", tokenID).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// UseRefreshToken marks a refresh token as rotated. It only succeeds once per token,
+// so two concurrent refreshes with the same token cannot both win.
+func (p *Postgres) UseRefreshToken(token *models.RefreshToken) error {
+	now := time.Now()
+	result := p.DB.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrRefreshTokenReused
+	}
+	token.UsedAt = &now
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token issued from the same login
+func (p *Postgres) RevokeRefreshTokenFamily(familyID string) error {
+	return p.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// CreateOneTimeToken saves a one-time token and discards any earlier unused token
+// issued to the same account for the same purpose
+func (p *Postgres) CreateOneTimeToken(token *models.OneTimeToken) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("purpose = ? AND account_id = ? AND used_at IS NULL", token.Purpose, token.AccountID).
+			Delete(&models.OneTimeToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// FindOneTimeToken looks up an unexpired, unused token without redeeming it
+func (p *Postgres) FindOneTimeToken(purpose models.TokenPurpose, tokenHash string) (*models.OneTimeToken, error) {
+	token := &models.OneTimeToken{}
+
+	if err := p.DB.Where("purpose = ? AND token_hash = ? AND used_at IS NULL AND expires_at > ?", purpose, tokenHash, time.Now()).
+		First(&token).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ConsumeOneTimeToken redeems an unexpired, unused token. It returns gorm.ErrRecordNotFound
+// if no such token exists or another request redeemed it first.
+func (p *Postgres) ConsumeOneTimeToken(purpose models.TokenPurpose, tokenHash string) (*models.OneTimeToken, error) {
+	token := &models.OneTimeToken{}
+	now := time.Now()
+
+	if err := p.DB.Where("purpose = ? AND token_hash = ? AND used_at IS NULL AND expires_at > ?", purpose, tokenHash, now).
+		First(&token).Error; err != nil {
+		return nil, err
+	}
+
+	result := p.DB.Model(&models.OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	token.UsedAt = &now
+	return token, nil
+}
+
+// ReplaceRecoveryCodes discards an account's recovery codes and saves a new set
+func (p *Postgres) ReplaceRecoveryCodes(accountID uint, codes []*models.RecoveryCode) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("account_id = ?", accountID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(codes).Error
+	})
+}
+
+// UseRecoveryCode redeems an unused recovery code. It returns gorm.ErrRecordNotFound
+// if the code does not exist or was already used.
+func (p *Postgres) UseRecoveryCode(accountID uint, codeHash string) error {
+	result := p.DB.Model(&models.RecoveryCode{}).
+		Where("account_id = ? AND code_hash = ? AND used_at IS NULL", accountID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -4,12 +4,17 @@ import (
 	"e-commerce/internal/models"
 	"errors"
 	"log"
+
+	"gorm.io/gorm"
 )
 
+// FindUserByEmail finds the buyer membership of the account with this email
 func (p *Postgres) FindUserByEmail(email string) (*models.User, error) {
 	user := &models.User{}
 
-	if err := p.DB.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := p.DB.Preload("Account").
+		Joins("JOIN accounts ON accounts.id = users.account_id AND accounts.deleted_at IS NULL").
+		Where("accounts.email = ?", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -18,7 +23,7 @@ func (p *Postgres) FindUserByEmail(email string) (*models.User, error) {
 func (p *Postgres) FindAllUsers() ([]models.User, error) {
 	var users []models.User
 
-	if err := p.DB.Find(&users).Error; err != nil {
+	if err := p.DB.Preload("Account").Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
@@ -27,13 +32,24 @@ func (p *Postgres) FindAllUsers() ([]models.User, error) {
 func (p *Postgres) GetUserByID(userID uint) (*models.User, error) {
 	user := &models.User{}
 
-	if err := p.DB.Where("ID = ?", userID).First(&user).Error; err != nil {
+	if err := p.DB.Preload("Account").Where("ID = ?", userID).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-// Create a user in the database
+// GetUserByAccountID returns the account's buyer membership
+func (p *Postgres) GetUserByAccountID(accountID uint) (*models.User, error) {
+	user := &models.User{}
+
+	if err := p.DB.Preload("Account").Where("account_id = ?", accountID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Create a user in the database. A new user.Account is created along with it;
+// an existing one just gains the buyer membership.
 func (p *Postgres) CreateUser(user *models.User) error {
 	if err := p.DB.Create(user).Error; err != nil {
 		return err
@@ -42,22 +58,70 @@ func (p *Postgres) CreateUser(user *models.User) error {
 	return nil
 }
 
-// Update a user in the database
+// Update a user and the profile fields of its account in the database.
+// Credentials are saved with UpdateAccount.
 func (p *Postgres) UpdateUser(user *models.User) error {
-	if err := p.DB.Save(user).Error; err != nil {
-		return err
-	}
-	return nil
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Account").Save(user).Error; err != nil {
+			return err
+		}
+		return saveAccountProfile(tx, user.Account)
+	})
 }
 
-// Get all products in the database
-func (p *Postgres) GetAllProducts() ([]models.Product, error) {
+// ListProducts returns one page of the catalogue and the number of products matching the query
+func (p *Postgres) ListProducts(query *models.ProductQuery) ([]models.Product, int64, error) {
 	var products []models.Product
+	var total int64
 
-	if err := p.DB.Find(&products).Error; err != nil {
-		return nil, err
+	filtered := filterProducts(p.DB.Model(&models.Product{}), query)
+	if err := filtered.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := sortProducts(filtered, query.Sort).
+		Offset(query.Offset()).Limit(query.Limit).
+		Find(&products).Error; err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// filterProducts applies the query's filters but not its order or page. Products that
+// are off sale never match.
+func filterProducts(db *gorm.DB, query *models.ProductQuery) *gorm.DB {
+	db = db.Where("products.status = ?", true)
+	if query.MinPrice != nil {
+		db = db.Where("products.price >= ?", *query.MinPrice)
+	}
+	if query.MaxPrice != nil {
+		db = db.Where("products.price <= ?", *query.MaxPrice)
+	}
+	if query.SellerID != 0 {
+		db = db.Where("products.seller_id = ?", query.SellerID)
 	}
-	return products, nil
+	if query.CategoryID != 0 {
+		db = db.Where("products.category_id IN (?)", categorySubtree(query.CategoryID))
+	}
+	if query.InStock {
+		db = db.Where("products.quantity > 0")
+	}
+	if query.CreatedAfter != nil {
+		db = db.Where("products.created_at > ?", *query.CreatedAfter)
+	}
+	return db
+}
+
+// sortProducts orders a listing; the id breaks ties so pages never overlap
+func sortProducts(db *gorm.DB, sort models.ProductSort) *gorm.DB {
+	switch sort {
+	case models.ProductSortPrice:
+		return db.Order("products.price ASC, products.id ASC")
+	case models.ProductSortPriceDesc:
+		return db.Order("products.price DESC, products.id ASC")
+	case models.ProductSortTitle:
+		return db.Order("products.title ASC, products.id ASC")
+	}
+	return db.Order("products.created_at DESC, products.id DESC")
 }
 
 // Get a product by its ID
@@ -95,22 +159,54 @@ func (p *Postgres) GetCartsByUserID(userID uint) ([]*models.IndividualItemInCart
 	return cartItems, nil
 }
 
-// create an order
-func (p *Postgres) CreateOrder(order *models.Order) error {
+// ListCartItems returns the user's cart, which may be empty
+func (p *Postgres) ListCartItems(userID uint) ([]*models.IndividualItemInCart, error) {
+	cartItems := []*models.IndividualItemInCart{}
+
+	if err := p.DB.Where("user_id = ? AND order_id IS NULL", userID).Find(&cartItems).Error; err != nil {
+		return nil, err
+	}
+	return cartItems, nil
+}
+
+// CreateOrders saves the orders of one checkout, takes their units out of stock and
+// clears the cart, all in one transaction
+func (p *Postgres) CreateOrders(orders []*models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
 	tx := p.DB.Begin()
 	if err := tx.Error; err != nil {
 		return err
 	}
 
-	// Attempt to create the order
-	if err := tx.Create(order).Error; err != nil {
-		tx.Rollback()
-		log.Printf("Error creating order: %v", err) // Add this log
-		return err
+	for _, order := range orders {
+		// Attempt to create the order
+		if err := tx.Create(order).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Error creating order: %v", err) // Add this log
+			return err
+		}
+
+		// Take the ordered units out of stock, failing if another order got them first
+		for _, item := range order.Items {
+			result := tx.Model(&models.Product{}).
+				Where("id = ? AND quantity >= ?", item.ProductID, item.Quantity).
+				Update("quantity", gorm.Expr("quantity - ?", item.Quantity))
+			if result.Error != nil {
+				tx.Rollback()
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				tx.Rollback()
+				return models.ErrInsufficientStock
+			}
+		}
 	}
 
 	// Clear the cart
-	if err := tx.Where("user_id = ?", order.UserID).Delete(&models.IndividualItemInCart{}).Error; err != nil {
+	if err := tx.Where("user_id = ?", orders[0].UserID).Delete(&models.IndividualItemInCart{}).Error; err != nil {
 		tx.Rollback()
 		log.Printf("Error clearing cart: %v", err) // Add this log
 		return err
@@ -137,16 +233,17 @@ func (p *Postgres) DeleteProductFromCart(cart *models.IndividualItemInCart) erro
 func (p *Postgres) GetOrdersByUserID(userID uint) ([]*models.Order, error) {
 	var orders []*models.Order
 
-	if err := p.DB.Find(&orders).Error; err != nil {
+	if err := p.DB.Where("user_id = ?", userID).Find(&orders).Error; err != nil {
 		return nil, err
 	}
 	return orders, nil
 }
 
-func (p *Postgres) GetCartItemByProductID(productID uint) (*models.IndividualItemInCart, error) {
+// GetCartItemByProductID finds the product's line in the user's open cart
+func (p *Postgres) GetCartItemByProductID(userID, productID uint) (*models.IndividualItemInCart, error) {
 	cart := &models.IndividualItemInCart{}
 
-	if err := p.DB.Where("product_id = ?", productID).First(&cart).Error; err != nil {
+	if err := p.DB.Where("user_id = ? AND product_id = ? AND order_id IS NULL", userID, productID).First(&cart).Error; err != nil {
 		return nil, err
 	}
 	return cart, nil
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"e-commerce/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetWishlist returns the user's wishlist with its products, newest first. A user who
+// has never saved anything gets an empty list rather than an error.
+func (p *Postgres) GetWishlist(userID uint) (*models.Wishlist, error) {
+	wishlist := &models.Wishlist{}
+	if err := withWishlistItems(p.DB).Where("user_id = ?", userID).Limit(1).Find(&wishlist).Error; err != nil {
+		return nil, err
+	}
+	wishlist.UserID = userID
+	return wishlist, nil
+}
+
+// GetWishlistByShareToken finds a shared wishlist by the hash of its share token
+func (p *Postgres) GetWishlistByShareToken(tokenHash string) (*models.Wishlist, error) {
+	wishlist := &models.Wishlist{}
+	if err := withWishlistItems(p.DB).Where("share_token_hash = ?", tokenHash).First(&wishlist).Error; err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+// AddToWishlist saves a product to the user's wishlist. Saving it twice is a no-op.
+func (p *Postgres) AddToWishlist(userID, productID uint) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		wishlist, err := findOrCreateWishlist(tx, userID)
+		if err != nil {
+			return err
+		}
+		item := &models.WishlistItem{WishlistID: wishlist.ID, ProductID: productID}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(item).Error
+	})
+}
+
+// RemoveFromWishlist returns gorm.ErrRecordNotFound if the product was not saved
+func (p *Postgres) RemoveFromWishlist(userID, productID uint) error {
+	return removeWishlistItem(p.DB, userID, productID)
+}
+
+// MoveWishlistItemToCart takes a product off the wishlist and saves the cart line in
+// one transaction, so the product is never in both or in neither
+func (p *Postgres) MoveWishlistItemToCart(userID uint, cart *models.IndividualItemInCart) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := removeWishlistItem(tx, userID, cart.ProductID); err != nil {
+			return err
+		}
+		return tx.Save(cart).Error
+	})
+}
+
+// SetWishlistShareToken starts sharing the wishlist under a new token, replacing any
+// earlier link, or stops sharing it when tokenHash is nil
+func (p *Postgres) SetWishlistShareToken(userID uint, tokenHash *string) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		wishlist, err := findOrCreateWishlist(tx, userID)
+		if err != nil {
+			return err
+		}
+		return tx.Model(wishlist).Update("share_token_hash", tokenHash).Error
+	})
+}
+
+func withWishlistItems(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Preload("Items.Product")
+}
+
+func findOrCreateWishlist(tx *gorm.DB, userID uint) (*models.Wishlist, error) {
+	wishlist := &models.Wishlist{}
+	if err := tx.Where(models.Wishlist{UserID: userID}).FirstOrCreate(&wishlist).Error; err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+func removeWishlistItem(tx *gorm.DB, userID, productID uint) error {
+	result := tx.Where("product_id = ? AND wishlist_id IN (?)", productID,
+		tx.Model(&models.Wishlist{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&models.WishlistItem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
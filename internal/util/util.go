@@ -2,8 +2,11 @@ package util
 
 import (
 	"e-commerce/internal/models"
+	"log"
 	"net/http"
+	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,20 +23,56 @@ func Response(c *gin.Context, message string, status int, data interface{}, errs
 		"timestamp": time.Now().Format("2006-01-02 15:04:05"),
 	}
 
-	c.IndentedJSON(status, responsedata)
+	c.JSON(status, responsedata)
+}
+
+// DefaultPasswordCost is the bcrypt cost used when BCRYPT_COST is unset
+const DefaultPasswordCost = 12
+
+var (
+	passwordCostOnce sync.Once
+	passwordCost     int
+)
+
+// PasswordCost returns the bcrypt cost from BCRYPT_COST, falling back to
+// DefaultPasswordCost if it is unset or out of bcrypt's range
+func PasswordCost() int {
+	passwordCostOnce.Do(func() {
+		passwordCost = DefaultPasswordCost
+		value := os.Getenv("BCRYPT_COST")
+		if value == "" {
+			return
+		}
+		cost, err := strconv.Atoi(value)
+		if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			log.Printf("invalid BCRYPT_COST %q, using %d\n", value, DefaultPasswordCost)
+			return
+		}
+		passwordCost = cost
+	})
+	return passwordCost
 }
 
 // HashPassword takes a plaintext password and returns the hashed password or an error
 func HashPassword(password string) (string, error) {
 
 	// Use bcrypt to generate a hashed password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost())
 	if err != nil {
 		return "", err
 	}
 	return string(hashedPassword), nil
 }
 
+// NeedsRehash reports whether hashedPassword was made with a lower cost than the configured one
+func NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return false
+	}
+	return cost < PasswordCost()
+}
+
 func ConvertStringToUint(s string) (uint, error) {
 	int, err := strconv.Atoi(s)
 	if err != nil {